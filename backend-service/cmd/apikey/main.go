@@ -0,0 +1,199 @@
+// Command apikey manages the API keys clients authenticate with.
+//
+//	go run ./cmd/apikey create -name grading-app -scopes analyze,origins:read -expires 2160h
+//	go run ./cmd/apikey list
+//	go run ./cmd/apikey quota <id or prefix> 5000
+//	go run ./cmd/apikey revoke <id or prefix>
+//
+// It connects to the database configured for the server.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/apikey"
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+const usage = `usage: apikey <command> [flags]
+
+commands:
+  create -name NAME -scopes SCOPES [-expires DURATION] [-quota CALLS]
+  list [-all]
+  quota ID|PREFIX CALLS|default
+  revoke ID|PREFIX
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	zerolog.SetGlobalLevel(zerolog.WarnLevel)
+	cfg := config.Load()
+	cfg.Env = "production" // keep SQL logging quiet
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		fail(err)
+	}
+	defer database.Close()
+	if err := database.Migrate(db); err != nil {
+		fail(err)
+	}
+
+	switch os.Args[1] {
+	case "create":
+		err = create(db, os.Args[2:])
+	case "list":
+		err = list(db, os.Args[2:])
+	case "quota":
+		err = quota(db, os.Args[2:])
+	case "revoke":
+		err = revoke(db, os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func create(db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	name := fs.String("name", "", "who or what the key is for")
+	scopeList := fs.String("scopes", "", "comma-separated scopes: "+strings.Join(models.Scopes, ", "))
+	expires := fs.Duration("expires", 0, "lifetime of the key, e.g. 720h (default never)")
+	monthlyQuota := fs.Int("quota", -1, "monthly inference calls, 0 for unlimited (default the server default)")
+	fs.Parse(args)
+
+	if strings.TrimSpace(*name) == "" {
+		return fmt.Errorf("-name is required")
+	}
+	scopes, err := apikey.ParseScopes(*scopeList)
+	if err != nil {
+		return err
+	}
+
+	var expiresAt *time.Time
+	if *expires > 0 {
+		t := time.Now().Add(*expires)
+		expiresAt = &t
+	}
+
+	var quota *int
+	if *monthlyQuota >= 0 {
+		quota = monthlyQuota
+	}
+
+	record, key, err := apikey.Create(db, strings.TrimSpace(*name), scopes, expiresAt, quota)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created API key %d (%s) with scopes %s\n", record.ID, record.Name, record.Scopes)
+	if expiresAt != nil {
+		fmt.Printf("Expires %s\n", expiresAt.Format(time.RFC3339))
+	}
+	fmt.Printf("\n  %s\n\nStore it now, it cannot be shown again.\n", key)
+	return nil
+}
+
+func list(db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	all := fs.Bool("all", false, "include revoked and expired keys")
+	fs.Parse(args)
+
+	var keys []models.APIKey
+	if err := db.Order("id").Find(&keys).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPREFIX\tNAME\tSCOPES\tQUOTA\tSTATUS\tEXPIRES\tLAST USED")
+	for _, k := range keys {
+		status := "active"
+		switch {
+		case k.RevokedAt != nil:
+			status = "revoked"
+		case !k.Active(now):
+			status = "expired"
+		}
+		if status != "active" && !*all {
+			continue
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.Prefix, k.Name, k.Scopes, formatQuota(k.MonthlyQuota), status, formatTime(k.ExpiresAt, "never"), formatTime(k.LastUsedAt, "never"))
+	}
+	return w.Flush()
+}
+
+func quota(db *gorm.DB, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("quota takes the id or prefix of one key and a number of calls or \"default\"")
+	}
+
+	var calls *int
+	if args[1] != "default" {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return fmt.Errorf("quota must be a non-negative number of calls or \"default\"")
+		}
+		calls = &n
+	}
+
+	record, err := apikey.SetQuota(db, args[0], calls)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Set monthly quota of API key %d (%s) to %s\n", record.ID, record.Name, formatQuota(record.MonthlyQuota))
+	return nil
+}
+
+func revoke(db *gorm.DB, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("revoke takes the id or prefix of one key")
+	}
+
+	record, err := apikey.Revoke(db, args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Revoked API key %d (%s)\n", record.ID, record.Name)
+	return nil
+}
+
+func formatQuota(quota *int) string {
+	switch {
+	case quota == nil:
+		return "default"
+	case *quota == 0:
+		return "unlimited"
+	default:
+		return strconv.Itoa(*quota)
+	}
+}
+
+func formatTime(t *time.Time, empty string) string {
+	if t == nil {
+		return empty
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
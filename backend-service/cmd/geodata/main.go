@@ -0,0 +1,243 @@
+// Command geodata builds the boundary datasets embedded by the geocode
+// package from Natural Earth 1:10m GeoJSON (public domain).
+//
+//	go run ./cmd/geodata -countries ne_10m_admin_0_countries.geojson \
+//		-admin1 ne_10m_admin_1_states_provinces.geojson -out internal/geocode/data
+//
+// Inputs may be gzip compressed. Geometries are simplified, coordinates
+// rounded and every property except names and codes is dropped.
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/beanspect/backend-service/internal/geo"
+)
+
+type feature struct {
+	Type       string                 `json:"type"`
+	Geometry   geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+type collection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+func main() {
+	countries := flag.String("countries", "", "Natural Earth admin 0 countries GeoJSON")
+	admin1 := flag.String("admin1", "", "Natural Earth admin 1 states/provinces GeoJSON")
+	out := flag.String("out", "internal/geocode/data", "output directory")
+	countryTolerance := flag.Float64("country-tolerance", 0.01, "country simplification tolerance in degrees")
+	admin1Tolerance := flag.Float64("admin1-tolerance", 0.02, "admin 1 simplification tolerance in degrees")
+	flag.Parse()
+
+	if *countries == "" || *admin1 == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := build(*countries, filepath.Join(*out, "countries.geojson.gz"), *countryTolerance, countryProperties); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := build(*admin1, filepath.Join(*out, "admin1.geojson.gz"), *admin1Tolerance, admin1Properties); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func countryProperties(p map[string]interface{}) map[string]interface{} {
+	iso2 := str(p, "ISO_A2_EH", "ISO_A2")
+	iso3 := str(p, "ISO_A3_EH", "ISO_A3", "ADM0_A3")
+	names := unique(str(p, "ADMIN"), str(p, "NAME"), str(p, "NAME_LONG"), str(p, "FORMAL_EN"), str(p, "NAME_ID"))
+	return map[string]interface{}{
+		"name":   str(p, "ADMIN", "NAME"),
+		"iso_a2": iso2,
+		"iso_a3": iso3,
+		"names":  names,
+	}
+}
+
+// admin1Properties returns nil for features that are not first-level
+// divisions, such as whole countries some extracts mix in
+func admin1Properties(p map[string]interface{}) map[string]interface{} {
+	if str(p, "admin") == "" {
+		return nil
+	}
+	names := unique(str(p, "name"), str(p, "name_en"), str(p, "gns_name"), str(p, "woe_name"))
+	for _, alt := range strings.Split(str(p, "name_alt"), "|") {
+		names = unique(append(names, strings.TrimSpace(alt))...)
+	}
+	return map[string]interface{}{
+		"name":    str(p, "name", "name_en"),
+		"country": str(p, "admin"),
+		"iso_a2":  str(p, "iso_a2"),
+		"code":    str(p, "iso_3166_2"),
+		"type":    str(p, "type_en", "type"),
+		"names":   names,
+	}
+}
+
+func build(in, out string, tolerance float64, props func(map[string]interface{}) map[string]interface{}) error {
+	src, err := read(in)
+	if err != nil {
+		return err
+	}
+
+	var fc collection
+	if err := json.Unmarshal(src, &fc); err != nil {
+		return fmt.Errorf("%s: %w", in, err)
+	}
+
+	result := collection{Type: "FeatureCollection"}
+	for _, f := range fc.Features {
+		properties := props(f.Properties)
+		if properties == nil {
+			continue
+		}
+		polys, err := polygons(f.Geometry)
+		if err != nil {
+			return fmt.Errorf("%s: %w", in, err)
+		}
+		polys = geo.SimplifyPolygons(polys, tolerance)
+		if len(polys) == 0 {
+			continue
+		}
+		coords, err := json.Marshal(rounded(polys))
+		if err != nil {
+			return err
+		}
+		result.Features = append(result.Features, feature{
+			Type:       "Feature",
+			Geometry:   geometry{Type: "MultiPolygon", Coordinates: coords},
+			Properties: properties,
+		})
+	}
+
+	file, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	zw, err := gzip.NewWriterLevel(file, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(zw).Encode(result); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("%s: %d features\n", out, len(result.Features))
+	return nil
+}
+
+func read(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	return io.ReadAll(r)
+}
+
+func polygons(g geometry) ([]geo.Polygon, error) {
+	switch g.Type {
+	case "Polygon":
+		var coords [][][2]float64
+		if err := json.Unmarshal(g.Coordinates, &coords); err != nil {
+			return nil, err
+		}
+		return []geo.Polygon{toPolygon(coords)}, nil
+	case "MultiPolygon":
+		var coords [][][][2]float64
+		if err := json.Unmarshal(g.Coordinates, &coords); err != nil {
+			return nil, err
+		}
+		polys := make([]geo.Polygon, len(coords))
+		for i, c := range coords {
+			polys[i] = toPolygon(c)
+		}
+		return polys, nil
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q", g.Type)
+	}
+}
+
+func toPolygon(coords [][][2]float64) geo.Polygon {
+	poly := make(geo.Polygon, len(coords))
+	for i, ring := range coords {
+		r := make(geo.Ring, len(ring))
+		for j, c := range ring {
+			r[j] = geo.Point{Lng: c[0], Lat: c[1]}
+		}
+		poly[i] = r
+	}
+	return poly
+}
+
+// rounded converts polygons to GeoJSON coordinates at 4 decimal places (~11m)
+func rounded(polys []geo.Polygon) [][][][2]float64 {
+	round := func(v float64) float64 { return math.Round(v*1e4) / 1e4 }
+	out := make([][][][2]float64, len(polys))
+	for i, poly := range polys {
+		out[i] = make([][][2]float64, len(poly))
+		for j, ring := range poly {
+			out[i][j] = make([][2]float64, len(ring))
+			for k, p := range ring {
+				out[i][j][k] = [2]float64{round(p.Lng), round(p.Lat)}
+			}
+		}
+	}
+	return out
+}
+
+func str(p map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if s, ok := p[k].(string); ok && s != "" && s != "-99" {
+			return s
+		}
+	}
+	return ""
+}
+
+func unique(items ...string) []string {
+	seen := make(map[string]bool, len(items))
+	var out []string
+	for _, item := range items {
+		key := strings.ToLower(item)
+		if item == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, item)
+	}
+	return out
+}
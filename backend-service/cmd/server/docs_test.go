@@ -0,0 +1,17 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/handlers"
+)
+
+// TestDocsDrift builds the API docs from the real router and fails on
+// every route or error code they miss or describe in vain
+func TestDocsDrift(t *testing.T) {
+	app := newApp(config.Load())
+	for _, problem := range handlers.CheckDocs(app) {
+		t.Error(problem)
+	}
+}
@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/beanspect/backend-service/internal/config"
 	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/geocode"
 	"github.com/beanspect/backend-service/internal/handlers"
+	"github.com/beanspect/backend-service/internal/metrics"
 	"github.com/beanspect/backend-service/internal/middleware"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/beanspect/backend-service/internal/ratelimit"
+	"github.com/beanspect/backend-service/internal/services"
+	"github.com/beanspect/backend-service/internal/tracing"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/recover"
@@ -21,6 +30,8 @@ func main() {
 	// Configure zerolog
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout})
+	// Contexts without a request logger log to the global logger
+	zerolog.DefaultContextLogger = &log.Logger
 
 	// Load configuration
 	cfg := config.Load()
@@ -30,6 +41,18 @@ func main() {
 		Str("env", cfg.Env).
 		Msg("Starting BeanSpect Backend Service")
 
+	// Set up tracing, a no-op without an OTLP endpoint
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to set up tracing")
+		shutdownTracing = func(context.Context) error { return nil }
+	}
+
+	// Load reverse geocoding boundaries
+	if _, err := geocode.Load(); err != nil {
+		log.Error().Err(err).Msg("Failed to load reverse geocoding boundaries")
+	}
+
 	// Connect to database
 	db, err := database.Connect(cfg)
 	if err != nil {
@@ -41,29 +64,33 @@ func main() {
 		if err := database.Migrate(db); err != nil {
 			log.Error().Err(err).Msg("Failed to run migrations")
 		}
+		if err := database.BackfillRanges(db); err != nil {
+			log.Error().Err(err).Msg("Failed to backfill species ranges")
+		}
 		// Seed initial data
 		if err := database.SeedSpeciesOrigins(db); err != nil {
 			log.Error().Err(err).Msg("Failed to seed species origins")
 		}
+		if err := database.SeedSpeciesTranslations(db); err != nil {
+			log.Error().Err(err).Msg("Failed to seed species translations")
+		}
+		if err := database.SeedFlavorWheel(db); err != nil {
+			log.Error().Err(err).Msg("Failed to seed flavor wheel")
+		}
+		if err := database.SeedSensoryProfiles(db); err != nil {
+			log.Error().Err(err).Msg("Failed to seed sensory profiles")
+		}
+		if err := database.SeedSpeciesClimate(db); err != nil {
+			log.Error().Err(err).Msg("Failed to seed species climate")
+		}
+		if err := database.SeedHarvestWindows(db); err != nil {
+			log.Error().Err(err).Msg("Failed to seed harvest calendar")
+		}
 	}
 
 	// Create Fiber app
-	app := fiber.New(fiber.Config{
-		AppName:      cfg.AppName,
-		ErrorHandler: errorHandler,
-	})
-
-	// Middleware
-	app.Use(recover.New())
-	app.Use(middleware.Logger())
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: joinOrigins(cfg.CORSOrigins),
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
-	}))
-
-	// Routes
-	setupRoutes(app)
+	app := newApp(cfg)
+	metricsApp := setupMetrics(app)
 
 	// Graceful shutdown
 	go func() {
@@ -74,6 +101,11 @@ func main() {
 		if err := app.Shutdown(); err != nil {
 			log.Error().Err(err).Msg("Error during shutdown")
 		}
+		if metricsApp != nil {
+			if err := metricsApp.Shutdown(); err != nil {
+				log.Error().Err(err).Msg("Error during metrics shutdown")
+			}
+		}
 	}()
 
 	// Start server
@@ -82,15 +114,53 @@ func main() {
 	if err := app.Listen(addr); err != nil {
 		log.Fatal().Err(err).Msg("Failed to start server")
 	}
+
+	// Flush the spans of the last requests
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to flush traces")
+	}
+}
+
+// newApp creates the API app with its middleware and routes
+func newApp(cfg *config.Config) *fiber.App {
+	app := fiber.New(fiber.Config{
+		AppName:      cfg.AppName,
+		ErrorHandler: middleware.ErrorHandler,
+	})
+
+	// Middleware
+	app.Use(recover.New())
+	app.Use(middleware.RequestID())
+	app.Use(middleware.Tracing())
+	app.Use(middleware.Logger())
+	if cfg.MetricsEnabled {
+		app.Use(middleware.Metrics())
+	}
+	app.Use(middleware.Locale())
+	app.Use(cors.New(cors.Config{
+		AllowOrigins:  joinOrigins(cfg.CORSOrigins),
+		AllowMethods:  "GET,POST,PUT,PATCH,DELETE,OPTIONS",
+		AllowHeaders:  "Origin,Content-Type,Accept,Accept-Language,Authorization,X-API-Key,X-Request-ID,traceparent,tracestate",
+		ExposeHeaders: "RateLimit-Policy,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After,Deprecation,Sunset,Link,X-Request-ID",
+	}))
+
+	// Routes
+	setupRoutes(app)
+	return app
 }
 
 func setupRoutes(app *fiber.App) {
+	cfg := config.Get()
+
 	// Root
 	app.Get("/", func(c *fiber.Ctx) error {
 		cfg := config.Get()
 		return c.JSON(fiber.Map{
 			"service": cfg.AppName,
 			"version": cfg.AppVersion,
+			"api":     "/api/v1",
 			"docs":    "/api/docs",
 			"health":  "/health",
 		})
@@ -99,37 +169,229 @@ func setupRoutes(app *fiber.App) {
 	// Health check
 	app.Get("/health", handlers.Health)
 
+	// Rate limits: every API call spends from the standard budget and
+	// inference calls also from their own
+	limiter := newRateLimitStore(cfg)
+	standardLimit := middleware.RateLimit(limiter, "standard", ratelimit.Limit{
+		PerMinute: cfg.RateLimitStandardPerMin,
+		Burst:     cfg.RateLimitStandardBurst,
+	})
+	inferenceLimit := middleware.RateLimit(limiter, "inference", ratelimit.Limit{
+		PerMinute: cfg.RateLimitInferencePerMin,
+		Burst:     cfg.RateLimitInferenceBurst,
+	})
+
 	// API routes
-	api := app.Group("/api")
+	api := app.Group("/api", standardLimit)
+
+	// Docs handler
+	docsHandler := handlers.NewDocsHandler()
+	api.Get("/docs", docsHandler.GetPage)
+	api.Get("/docs/openapi.json", docsHandler.GetSpecJSON)
+	api.Get("/docs/openapi.yaml", docsHandler.GetSpecYAML)
+
+	// API versions are mounted side by side under /api/<version>. A new
+	// version registers its routes on its own group, reusing the v1
+	// handlers whose contract it keeps.
+	setupV1Routes(api.Group("/v1"), inferenceLimit)
+
+	// The legacy unversioned routes alias v1 until their sunset
+	aliasRoutes(app, "/api/v1", "/api",
+		middleware.Deprecated("/api", "/api/v1", cfg.LegacyAPIDeprecatedAt, cfg.LegacyAPISunset))
+}
+
+// setupV1Routes registers the v1 API on router
+func setupV1Routes(router fiber.Router, inferenceLimit fiber.Handler) {
+	// Scope checks, applied per route group
+	analyze := middleware.RequireScope(models.ScopeAnalyze)
+	read := middleware.RequireScope(models.ScopeOriginsRead)
+	write := middleware.RequireScope(models.ScopeOriginsWrite)
+
+	// Auth handler
+	authHandler := handlers.NewAuthHandler()
+	router.Post("/auth/register", authHandler.Register)
+	router.Post("/auth/login", authHandler.Login)
+	router.Post("/auth/refresh", authHandler.Refresh)
+	router.Post("/auth/logout", authHandler.Logout)
+	router.Get("/auth/me", middleware.RequireUser(), authHandler.Me)
 
 	// Predict handler
 	predictHandler := handlers.NewPredictHandler()
-	api.Post("/predict", predictHandler.Predict)
+	router.Post("/predict", analyze, inferenceLimit, middleware.Meter(models.EndpointPredict), predictHandler.Predict)
 
 	// Origin handler
 	originHandler := handlers.NewOriginHandler()
-	api.Get("/origins", originHandler.GetAllOrigins)
-	api.Get("/origins/geojson", originHandler.GetOriginGeoJSON)
-	api.Get("/origin/:species", originHandler.GetOriginBySpecies)
+	router.Get("/origins", read, originHandler.GetAllOrigins)
+	router.Get("/origins/geojson", read, originHandler.GetOriginGeoJSON)
+	router.Get("/origins/export", read, originHandler.ExportOrigins)
+	router.Post("/origins/import", write, originHandler.ImportOrigins)
+	router.Get("/origins/countries.geojson", read, originHandler.GetCountriesGeoJSON)
+	router.Post("/origins/production/import", write, originHandler.ImportProduction)
+	router.Get("/origin/:species", read, originHandler.GetOriginBySpecies)
+
+	// Species handler
+	speciesHandler := handlers.NewSpeciesHandler()
+	router.Get("/species", read, speciesHandler.ListSpecies)
+	router.Get("/species/radar", read, speciesHandler.GetRadar)
+	router.Get("/species/compare", read, speciesHandler.CompareSpecies)
+	router.Get("/species/:species/similar", read, speciesHandler.GetSimilar)
+	router.Get("/flavors", read, speciesHandler.GetFlavorWheel)
 
 	// Analyze handler
 	analyzeHandler := handlers.NewAnalyzeHandler()
-	api.Post("/analyze", analyzeHandler.Analyze)
+	router.Post("/analyze", analyze, inferenceLimit, middleware.Meter(models.EndpointAnalyze), analyzeHandler.Analyze)
+
+	// Geo handler
+	geoHandler := handlers.NewGeoHandler()
+	router.Get("/geo/reverse", read, geoHandler.Reverse)
+
+	// Suitability handler
+	suitabilityHandler := handlers.NewSuitabilityHandler()
+	router.Post("/suitability", read, suitabilityHandler.Evaluate)
+
+	// Calendar handler
+	calendarHandler := handlers.NewCalendarHandler()
+	router.Get("/calendar", read, calendarHandler.GetCalendar)
+	router.Get("/calendar/:species.ics", read, calendarHandler.ExportCalendar)
+
+	// Analysis handler
+	analysisHandler := handlers.NewAnalysisHandler()
+	router.Get("/analyses/heatmap", read, analysisHandler.GetHeatmap)
+
+	// Usage handler
+	usageHandler := handlers.NewUsageHandler()
+	router.Get("/usage", analyze, usageHandler.GetUsage)
+
+	// Tile handler
+	tileHandler := handlers.NewTileHandler()
+	router.Get("/tiles", read, tileHandler.ListLayers)
+	router.Get("/tiles/:layer.json", read, tileHandler.GetTileJSON)
+	router.Get("/tiles/:layer/:z/:x/:y.mvt", read, tileHandler.GetTile)
+
+	// Basemap handler
+	basemapHandler := handlers.NewBasemapHandler()
+	router.Get("/basemap", read, basemapHandler.ListBasemaps)
+	router.Get("/basemap/:name.json", read, basemapHandler.GetTileJSON)
+	router.Get("/basemap/:name/:z/:x/:y", read, basemapHandler.GetTile)
+
+	// Translation admin handler
+	translationHandler := handlers.NewTranslationHandler()
+	admin := router.Group("/admin", middleware.RequireScope(models.ScopeAdmin))
+	admin.Get("/translations", translationHandler.ListTranslations)
+	admin.Put("/translations", translationHandler.UpsertTranslation)
+	admin.Delete("/translations/:id", translationHandler.DeleteTranslation)
+
+	// User admin handler
+	admin.Get("/users", authHandler.ListUsers)
+	admin.Patch("/users/:id", authHandler.UpdateUser)
+
+	// Usage report handler
+	admin.Get("/usage", usageHandler.GetUsageReport)
+
+	// Audit handler
+	auditHandler := handlers.NewAuditHandler()
+	admin.Get("/audit-logs", auditHandler.ListAuditLogs)
 }
 
-func errorHandler(c *fiber.Ctx, err error) error {
-	code := fiber.StatusInternalServerError
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
+// aliasRoutes registers every route under the from prefix again under the
+// to prefix, with the front handlers ahead of each endpoint. Middleware of
+// groups beneath from is copied too, registered once ahead of the aliased
+// endpoints of every method so it never guards fewer routes than before;
+// middleware on from itself would also match the original routes through
+// the alias prefix and is left out.
+func aliasRoutes(app *fiber.App, from, to string, front ...fiber.Handler) {
+	// Middleware is listed once per method, like an endpoint of that
+	// method; only endpoints survive the use filter
+	endpoints := make(map[string]bool)
+	for _, route := range app.GetRoutes(true) {
+		endpoints[route.Method+" "+route.Path] = true
 	}
 
-	log.Error().Err(err).Int("status", code).Msg("Request error")
+	used := make(map[string]bool)
+	for _, route := range app.GetRoutes() {
+		if !strings.HasPrefix(route.Path, from+"/") {
+			continue
+		}
+		path := to + strings.TrimPrefix(route.Path, from)
+
+		if !endpoints[route.Method+" "+route.Path] {
+			if !used[path] {
+				used[path] = true
+				args := []interface{}{path}
+				for _, h := range route.Handlers {
+					args = append(args, h)
+				}
+				app.Use(args...)
+			}
+			continue
+		}
+		handlers := append(append([]fiber.Handler{}, front...), route.Handlers...)
+		app.Add(route.Method, path, handlers...)
+	}
+}
 
-	return c.Status(code).JSON(fiber.Map{
-		"error":   true,
-		"code":    "INTERNAL_ERROR",
-		"message": err.Error(),
+// setupMetrics exports the database pool and the health of dependencies
+// and serves /metrics: on the API port behind the metrics scope, or on
+// METRICS_ADDR without authentication, in which case the app listening
+// there is returned
+func setupMetrics(app *fiber.App) *fiber.App {
+	cfg := config.Get()
+	if !cfg.MetricsEnabled {
+		return nil
+	}
+
+	if db := database.Get(); db != nil {
+		if sqlDB, err := db.DB(); err == nil {
+			if err := metrics.RegisterDB(sqlDB); err != nil {
+				log.Error().Err(err).Msg("Failed to export database pool metrics")
+			}
+		}
+	}
+	inference := services.NewInferenceClient()
+	err := metrics.RegisterHealth(2*time.Second, map[string]metrics.HealthCheck{
+		"database": func(ctx context.Context) bool {
+			db := database.Get()
+			if db == nil {
+				return false
+			}
+			sqlDB, err := db.DB()
+			return err == nil && sqlDB.PingContext(ctx) == nil
+		},
+		"inference": func(ctx context.Context) bool {
+			ok, err := inference.HealthCheck(ctx)
+			return err == nil && ok
+		},
 	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to export health metrics")
+	}
+
+	if cfg.MetricsAddr == "" {
+		app.Get("/metrics", middleware.RequireScope(models.ScopeMetrics), metrics.Handler())
+		return nil
+	}
+
+	metricsApp := fiber.New(fiber.Config{DisableStartupMessage: true})
+	metricsApp.Get("/metrics", metrics.Handler())
+	go func() {
+		log.Info().Str("address", cfg.MetricsAddr).Msg("Metrics server starting")
+		if err := metricsApp.Listen(cfg.MetricsAddr); err != nil {
+			log.Error().Err(err).Msg("Failed to start metrics server")
+		}
+	}()
+	return metricsApp
+}
+
+// newRateLimitStore returns the configured rate limit store, falling back
+// to memory when Postgres is selected but not connected
+func newRateLimitStore(cfg *config.Config) ratelimit.Store {
+	if cfg.RateLimitStore == "postgres" {
+		if db := database.Get(); db != nil {
+			return ratelimit.NewPostgresStore(db)
+		}
+		log.Warn().Msg("Rate limit store is postgres but the database is not connected, using memory")
+	}
+	return ratelimit.NewMemoryStore()
 }
 
 func joinOrigins(origins []string) string {
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/beanspect/backend-service/internal/services"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	callerTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	callerSpanID  = "00f067aa0ba902b7"
+)
+
+// TestAnalyzeTrace drives an analyze request through the app and checks
+// that it continues the caller's trace, with child spans for the upload,
+// the inference call and the origin lookup, and that the trace reaches
+// the inference service
+func TestAnalyzeTrace(t *testing.T) {
+	var upstreamTraceparent string
+	inference := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get("traceparent")
+		json.NewEncoder(w).Encode(services.PredictionResponse{
+			PredictedClass: "arabica",
+			Confidence:     0.93,
+			AllPredictions: []services.ClassPrediction{{Class: "arabica", Confidence: 0.93}},
+		})
+	}))
+	defer inference.Close()
+
+	t.Setenv("AUTH_ENABLED", "false")
+	t.Setenv("METRICS_ENABLED", "false")
+	t.Setenv("INFERENCE_SERVICE_URL", inference.URL)
+	cfg := config.Load()
+
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	useTestDatabase(t, models.SpeciesOrigin{Species: "arabica", CommonName: "Arabica", Country: "Ethiopia", Latitude: 9.145, Longitude: 40.4897})
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "bean.jpg")
+	part.Write([]byte("not really a jpeg"))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/analyze", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("traceparent", "00-"+callerTraceID+"-"+callerSpanID+"-01")
+
+	resp, err := newApp(cfg).Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("analyze answered %d, want 200", resp.StatusCode)
+	}
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+
+	server, ok := spans["POST /api/v1/analyze"]
+	if !ok {
+		t.Fatalf("no server span among %v", spanNames(exporter.GetSpans()))
+	}
+	if server.SpanKind != trace.SpanKindServer {
+		t.Errorf("server span kind = %v, want server", server.SpanKind)
+	}
+	if got := server.SpanContext.TraceID().String(); got != callerTraceID {
+		t.Errorf("server span trace = %s, want the caller's %s", got, callerTraceID)
+	}
+	if got := server.Parent.SpanID().String(); got != callerSpanID {
+		t.Errorf("server span parent = %s, want the caller's %s", got, callerSpanID)
+	}
+
+	for _, name := range []string{"multipart.parse", "inference.predict", "origins.lookup"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("no %s span among %v", name, spanNames(exporter.GetSpans()))
+			continue
+		}
+		if span.Parent.SpanID() != server.SpanContext.SpanID() {
+			t.Errorf("%s span is not a child of the server span", name)
+		}
+		if span.Status.Code != 0 {
+			t.Errorf("%s span failed: %s", name, span.Status.Description)
+		}
+	}
+
+	predict := spans["inference.predict"]
+	want := "00-" + callerTraceID + "-" + predict.SpanContext.SpanID().String() + "-01"
+	if upstreamTraceparent != want {
+		t.Errorf("inference service got traceparent %q, want %q", upstreamTraceparent, want)
+	}
+}
+
+// useTestDatabase connects the app to an in-memory SQLite database holding
+// origins
+func useTestDatabase(t *testing.T, origins ...models.SpeciesOrigin) {
+	t.Helper()
+	// The pure Go driver the GeoPackage export already registers
+	dialector := sqlite.Dialector{DriverName: "sqlite", DSN: "file::memory:"}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to :memory: opens a database of its own
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if len(origins) > 0 {
+		if err := db.Create(&origins).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := database.Use(db); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func spanNames(spans tracetest.SpanStubs) string {
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,129 @@
+// Command user manages the accounts that sign in to the API. Accounts are
+// created through registration; this command appoints the first admin of
+// a fresh install and lists who holds which role.
+//
+//	go run ./cmd/user list
+//	go run ./cmd/user role grader@example.com admin
+//
+// It connects to the database configured for the server.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/auth"
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+const usage = `usage: user <command> [args]
+
+commands:
+  list
+  role EMAIL ROLE
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	zerolog.SetGlobalLevel(zerolog.WarnLevel)
+	cfg := config.Load()
+	cfg.Env = "production" // keep SQL logging quiet
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		fail(err)
+	}
+	defer database.Close()
+	if err := database.Migrate(db); err != nil {
+		fail(err)
+	}
+
+	switch os.Args[1] {
+	case "list":
+		err = list(db)
+	case "role":
+		err = role(db, os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func list(db *gorm.DB) error {
+	var users []models.User
+	if err := db.Order("id").Find(&users).Error; err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tNAME\tROLE\tSTATUS\tLAST LOGIN")
+	for _, u := range users {
+		status := "active"
+		if u.Disabled {
+			status = "disabled"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", u.ID, u.Email, u.Name, u.Role, status, formatTime(u.LastLoginAt, "never"))
+	}
+	return w.Flush()
+}
+
+// role changes the role of a registered account and signs it out, so its
+// next tokens carry the new role
+func role(db *gorm.DB, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("role takes the email of one account and a role")
+	}
+	email, newRole := strings.ToLower(strings.TrimSpace(args[0])), args[1]
+	if !slices.Contains(models.Roles, newRole) {
+		return fmt.Errorf("invalid role %q, expected one of: %s", newRole, strings.Join(models.Roles, ", "))
+	}
+
+	var user models.User
+	err := db.Where("email = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("no account is registered with %s", email)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("role", newRole).Error; err != nil {
+			return err
+		}
+		return auth.RevokeUser(tx, user.ID)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Set role of user %d (%s) to %s\n", user.ID, user.Email, newRole)
+	return nil
+}
+
+func formatTime(t *time.Time, empty string) string {
+	if t == nil {
+		return empty
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
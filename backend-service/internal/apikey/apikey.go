@@ -0,0 +1,176 @@
+// Package apikey issues and verifies hashed API keys
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// keyPrefix marks BeanSpect API keys so they are easy to spot in
+	// configuration and secret scanners
+	keyPrefix = "bsk_"
+	// secretBytes is the random part of a key
+	secretBytes = 24
+	// displayLength is how much of a key is kept in clear to identify it
+	displayLength = len(keyPrefix) + 8
+	// touchInterval throttles last-used updates to one write per key
+	touchInterval = time.Minute
+)
+
+var (
+	// ErrInvalid is returned for keys that do not exist
+	ErrInvalid = errors.New("invalid API key")
+	// ErrRevoked is returned for revoked keys
+	ErrRevoked = errors.New("API key has been revoked")
+	// ErrExpired is returned for keys past their expiry
+	ErrExpired = errors.New("API key has expired")
+)
+
+// Hash returns the stored form of a key
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseScopes splits and validates a comma-separated scope list
+func ParseScopes(s string) ([]string, error) {
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, scope := range strings.Split(s, ",") {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" || seen[scope] {
+			continue
+		}
+		if !validScope(scope) {
+			return nil, fmt.Errorf("unknown scope %q, expected one of: %s", scope, strings.Join(models.Scopes, ", "))
+		}
+		seen[scope] = true
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	return scopes, nil
+}
+
+func validScope(scope string) bool {
+	for _, s := range models.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Create issues a new key and returns its record and the key itself,
+// which cannot be recovered later. A nil quota uses the default.
+func Create(db *gorm.DB, name string, scopes []string, expiresAt *time.Time, quota *int) (*models.APIKey, string, error) {
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	key := keyPrefix + hex.EncodeToString(secret)
+
+	record := &models.APIKey{
+		Name:         name,
+		Prefix:       key[:displayLength],
+		KeyHash:      Hash(key),
+		Scopes:       strings.Join(scopes, ","),
+		ExpiresAt:    expiresAt,
+		MonthlyQuota: quota,
+	}
+	if err := db.Create(record).Error; err != nil {
+		return nil, "", err
+	}
+	return record, key, nil
+}
+
+// Authenticate looks up an active key and records that it was used
+func Authenticate(db *gorm.DB, key string) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, ErrInvalid
+	}
+
+	var record models.APIKey
+	err := db.Where("key_hash = ?", Hash(key)).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if record.RevokedAt != nil {
+		return nil, ErrRevoked
+	}
+	if !record.Active(now) {
+		return nil, ErrExpired
+	}
+
+	if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) >= touchInterval {
+		if err := db.Model(&record).UpdateColumn("last_used_at", now).Error; err != nil {
+			return nil, err
+		}
+		record.LastUsedAt = &now
+	}
+	return &record, nil
+}
+
+// Find returns the key with the given id or prefix
+func Find(db *gorm.DB, idOrPrefix string) (*models.APIKey, error) {
+	var record models.APIKey
+	query := db.Where("prefix = ?", idOrPrefix)
+	if id, err := strconv.ParseUint(idOrPrefix, 10, 64); err == nil {
+		query = db.Where("id = ?", id)
+	}
+	if err := query.First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalid
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// SetQuota overrides the monthly quota of the key with the given id or
+// prefix; nil restores the default
+func SetQuota(db *gorm.DB, idOrPrefix string, quota *int) (*models.APIKey, error) {
+	record, err := Find(db, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Model(record).Update("monthly_quota", quota).Error; err != nil {
+		return nil, err
+	}
+	record.MonthlyQuota = quota
+	return record, nil
+}
+
+// Revoke revokes the key with the given id or prefix
+func Revoke(db *gorm.DB, idOrPrefix string) (*models.APIKey, error) {
+	record, err := Find(db, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if record.RevokedAt != nil {
+		return record, nil
+	}
+
+	now := time.Now()
+	if err := db.Model(record).Update("revoked_at", now).Error; err != nil {
+		return nil, err
+	}
+	record.RevokedAt = &now
+	return record, nil
+}
@@ -0,0 +1,122 @@
+// Package apperr defines the errors handlers return to answer a request
+// with an error response
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error is an application error. Code identifies the error to clients and
+// selects its translation; the message is safe to show to users while
+// Cause stays internal.
+type Error struct {
+	Status  int
+	Code    string
+	Format  string // English message format, localized by code
+	Args    []interface{}
+	Cause   error
+	Details interface{}
+}
+
+// ErrorBody is the JSON body of every error response
+type ErrorBody struct {
+	Error     bool        `json:"error"`
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+	Cause     string      `json:"cause,omitempty"` // outside production only
+}
+
+// New creates an error answered with status
+func New(status int, code, format string, args ...interface{}) *Error {
+	return &Error{Status: status, Code: code, Format: format, Args: args}
+}
+
+// BadRequest creates a 400 error
+func BadRequest(code, format string, args ...interface{}) *Error {
+	return New(http.StatusBadRequest, code, format, args...)
+}
+
+// Unauthorized creates a 401 error
+func Unauthorized(code, format string, args ...interface{}) *Error {
+	return New(http.StatusUnauthorized, code, format, args...)
+}
+
+// Forbidden creates a 403 error
+func Forbidden(code, format string, args ...interface{}) *Error {
+	return New(http.StatusForbidden, code, format, args...)
+}
+
+// NotFound creates a 404 error
+func NotFound(code, format string, args ...interface{}) *Error {
+	return New(http.StatusNotFound, code, format, args...)
+}
+
+// Conflict creates a 409 error
+func Conflict(code, format string, args ...interface{}) *Error {
+	return New(http.StatusConflict, code, format, args...)
+}
+
+// Unprocessable creates a 422 error
+func Unprocessable(code, format string, args ...interface{}) *Error {
+	return New(http.StatusUnprocessableEntity, code, format, args...)
+}
+
+// TooManyRequests creates a 429 error
+func TooManyRequests(code, format string, args ...interface{}) *Error {
+	return New(http.StatusTooManyRequests, code, format, args...)
+}
+
+// Internal creates a 500 error
+func Internal(code, format string, args ...interface{}) *Error {
+	return New(http.StatusInternalServerError, code, format, args...)
+}
+
+// Unavailable creates a 503 error
+func Unavailable(code, format string, args ...interface{}) *Error {
+	return New(http.StatusServiceUnavailable, code, format, args...)
+}
+
+// WithCause returns a copy of e caused by err
+func (e *Error) WithCause(err error) *Error {
+	c := *e
+	c.Cause = err
+	return &c
+}
+
+// WithDetails returns a copy of e carrying details for the client
+func (e *Error) WithDetails(details interface{}) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// Message returns the English message
+func (e *Error) Message() string {
+	if len(e.Args) == 0 {
+		return e.Format
+	}
+	return fmt.Sprintf(e.Format, e.Args...)
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Code + ": " + e.Message() + ": " + e.Cause.Error()
+	}
+	return e.Code + ": " + e.Message()
+}
+
+// Unwrap returns the cause
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// As finds the application error in err's chain
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
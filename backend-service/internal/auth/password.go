@@ -0,0 +1,63 @@
+// Package auth implements password login with JWT access tokens and
+// rotating refresh tokens
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password length limits. bcrypt ignores everything past 72 bytes.
+const (
+	MinPasswordLength = 10
+	MaxPasswordLength = 72
+)
+
+// passwordCost is the bcrypt work factor
+const passwordCost = 12
+
+// ValidatePassword checks a new password against the length limits
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("password must be at most %d bytes", MaxPasswordLength)
+	}
+	return nil
+}
+
+// HashPassword returns the bcrypt hash of password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches hash
+func CheckPassword(hash, password string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	return err == nil
+}
+
+var (
+	dummyOnce sync.Once
+	dummyHash []byte
+)
+
+// CheckNoUser spends the time of a password check when a login names an
+// unknown user, so the response takes as long as for a wrong password
+func CheckNoUser(password string) {
+	dummyOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("beanspect-dummy-password"), passwordCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// ErrInvalidCredentials is returned for a wrong email or password
+var ErrInvalidCredentials = errors.New("invalid email or password")
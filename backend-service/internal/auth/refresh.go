@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// ErrUserDisabled is returned when a disabled user signs in or refreshes
+var ErrUserDisabled = errors.New("user account is disabled")
+
+// RefreshTokenTTL is the lifetime of refresh tokens
+func RefreshTokenTTL() time.Duration {
+	return time.Duration(config.Get().RefreshTokenTTL) * time.Hour
+}
+
+// Client identifies where a refresh token was issued
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// IssueRefreshToken creates a refresh token for user. An empty family
+// starts a new one, as on login.
+func IssueRefreshToken(db *gorm.DB, user *models.User, family string, client Client) (string, error) {
+	if family == "" {
+		family = randomHex(16)
+	}
+	token := randomHex(32)
+
+	userAgent := client.UserAgent
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	record := models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		Family:    family,
+		ExpiresAt: time.Now().Add(RefreshTokenTTL()),
+		UserAgent: userAgent,
+		IP:        client.IP,
+	}
+	if err := db.Create(&record).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Rotate exchanges a refresh token for a new one from the same family.
+// Presenting a token that was already used revokes the family, since
+// either the client or an attacker holds a stolen copy.
+func Rotate(db *gorm.DB, token string, client Client) (*models.User, string, error) {
+	var record models.RefreshToken
+	if err := db.Where("token_hash = ?", hashToken(token)).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrInvalidToken
+		}
+		return nil, "", err
+	}
+
+	now := time.Now()
+	if record.RevokedAt != nil || now.After(record.ExpiresAt) {
+		return nil, "", ErrInvalidToken
+	}
+
+	// Claim the token; a concurrent or repeated use finds it taken
+	result := db.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL", record.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		log.Warn().Uint("user_id", record.UserID).Str("family", record.Family).Msg("Refresh token reused, revoking family")
+		if err := RevokeFamily(db, record.Family); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrInvalidToken
+	}
+
+	var user models.User
+	if err := db.First(&user, record.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrInvalidToken
+		}
+		return nil, "", err
+	}
+	if user.Disabled {
+		return nil, "", ErrUserDisabled
+	}
+
+	next, err := IssueRefreshToken(db, &user, record.Family, client)
+	if err != nil {
+		return nil, "", err
+	}
+	return &user, next, nil
+}
+
+// Revoke revokes the family of a refresh token, signing out the session
+// it belongs to
+func Revoke(db *gorm.DB, token string) error {
+	var record models.RefreshToken
+	if err := db.Where("token_hash = ?", hashToken(token)).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+	return RevokeFamily(db, record.Family)
+}
+
+// RevokeFamily revokes every outstanding token of a family
+func RevokeFamily(db *gorm.DB, family string) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("family = ? AND revoked_at IS NULL", family).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUser revokes every outstanding token of a user, signing them out
+// everywhere once their access tokens expire
+func RevokeUser(db *gorm.DB, userID uint) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"crypto/rand"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// issuer is the iss claim of access tokens
+const issuer = "beanspect"
+
+// ErrInvalidToken is returned for access or refresh tokens that are
+// malformed, expired, revoked or signed with another key
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Claims are the claims of an access token
+type Claims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the id of the user the token was issued to
+func (c *Claims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 64)
+	return uint(id), err
+}
+
+var (
+	secretOnce sync.Once
+	secret     []byte
+)
+
+// signingKey returns the configured JWT secret. Without one a random key
+// is used, which invalidates tokens on every restart.
+func signingKey() []byte {
+	secretOnce.Do(func() {
+		if s := config.Get().JWTSecret; s != "" {
+			secret = []byte(s)
+			return
+		}
+		log.Warn().Msg("JWT_SECRET is not set, using a random key; access tokens will not survive a restart")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+	})
+	return secret
+}
+
+// AccessTokenTTL is the lifetime of access tokens
+func AccessTokenTTL() time.Duration {
+	return time.Duration(config.Get().AccessTokenTTL) * time.Minute
+}
+
+// IssueAccessToken signs a short-lived access token for user
+func IssueAccessToken(user *models.User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(AccessTokenTTL())
+	claims := Claims{
+		Role: user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(signingKey())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// ParseAccessToken verifies an access token and returns its claims
+func ParseAccessToken(token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return signingKey(), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if _, err := claims.UserID(); err != nil {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog/log"
@@ -33,6 +34,47 @@ type Config struct {
 
 	// CORS
 	CORSOrigins []string
+
+	// Vector Tiles
+	TileCacheSize int
+	TileMaxAge    int
+
+	// Offline Basemaps
+	BasemapMBTiles []string
+
+	// Elevation Model
+	DEMPath string
+
+	// Localization
+	SupportedLocales []string
+
+	// Authentication
+	AuthEnabled     bool
+	JWTSecret       string
+	AccessTokenTTL  int // minutes
+	RefreshTokenTTL int // hours
+
+	// Rate Limiting
+	RateLimitStore           string // memory, postgres
+	RateLimitInferencePerMin int
+	RateLimitInferenceBurst  int
+	RateLimitStandardPerMin  int
+	RateLimitStandardBurst   int
+
+	// Usage Metering
+	UsageDefaultMonthlyQuota int // inference calls, 0 for unlimited
+
+	// API Versioning
+	LegacyAPIDeprecatedAt time.Time
+	LegacyAPISunset       time.Time
+
+	// Metrics
+	MetricsEnabled bool
+	MetricsAddr    string // separate listener for /metrics, empty to serve it on the API port
+
+	// Tracing
+	OTLPEndpoint    string // OTLP/HTTP collector URL, empty to disable tracing
+	OTELServiceName string
 }
 
 var cfg *Config
@@ -67,6 +109,47 @@ func Load() *Config {
 
 		// CORS
 		CORSOrigins: getEnvAsSlice("CORS_ORIGINS", []string{"http://localhost:3000", "http://localhost:5173"}),
+
+		// Vector Tiles
+		TileCacheSize: getEnvAsInt("TILE_CACHE_SIZE", 2048),
+		TileMaxAge:    getEnvAsInt("TILE_MAX_AGE", 300),
+
+		// Offline Basemaps
+		BasemapMBTiles: getEnvAsSlice("BASEMAP_MBTILES", []string{}),
+
+		// Elevation Model
+		DEMPath: getEnv("DEM_PATH", ""),
+
+		// Localization
+		SupportedLocales: getEnvAsSlice("SUPPORTED_LOCALES", []string{"en", "id"}),
+
+		// Authentication
+		AuthEnabled:     getEnvAsBool("AUTH_ENABLED", true),
+		JWTSecret:       getEnv("JWT_SECRET", ""),
+		AccessTokenTTL:  getEnvAsInt("ACCESS_TOKEN_TTL", 15),
+		RefreshTokenTTL: getEnvAsInt("REFRESH_TOKEN_TTL", 720),
+
+		// Rate Limiting
+		RateLimitStore:           getEnv("RATE_LIMIT_STORE", "memory"),
+		RateLimitInferencePerMin: getEnvAsInt("RATE_LIMIT_INFERENCE_PER_MINUTE", 20),
+		RateLimitInferenceBurst:  getEnvAsInt("RATE_LIMIT_INFERENCE_BURST", 5),
+		RateLimitStandardPerMin:  getEnvAsInt("RATE_LIMIT_STANDARD_PER_MINUTE", 300),
+		RateLimitStandardBurst:   getEnvAsInt("RATE_LIMIT_STANDARD_BURST", 100),
+
+		// Usage Metering
+		UsageDefaultMonthlyQuota: getEnvAsInt("USAGE_DEFAULT_MONTHLY_QUOTA", 0),
+
+		// API Versioning
+		LegacyAPIDeprecatedAt: getEnvAsDate("LEGACY_API_DEPRECATED_AT", time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)),
+		LegacyAPISunset:       getEnvAsDate("LEGACY_API_SUNSET", time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)),
+
+		// Metrics
+		MetricsEnabled: getEnvAsBool("METRICS_ENABLED", true),
+		MetricsAddr:    getEnv("METRICS_ADDR", ""),
+
+		// Tracing
+		OTLPEndpoint:    getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		OTELServiceName: getEnv("OTEL_SERVICE_NAME", "beanspect-backend"),
 	}
 
 	return cfg
@@ -98,10 +181,40 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
-// getEnvAsSlice gets an environment variable as a comma-separated slice
+// getEnvAsBool gets an environment variable as boolean or returns a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsDate gets an environment variable as a YYYY-MM-DD date in UTC or
+// returns a default value
+func getEnvAsDate(key string, defaultValue time.Time) time.Time {
+	if value, exists := os.LookupEnv(key); exists {
+		if date, err := time.Parse("2006-01-02", value); err == nil {
+			return date
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsSlice gets an environment variable as a comma-separated slice,
+// skipping blank items, or returns a default value when it lists none
 func getEnvAsSlice(key string, defaultValue []string) []string {
 	if value, exists := os.LookupEnv(key); exists {
-		return strings.Split(value, ",")
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		if len(items) > 0 {
+			return items
+		}
 	}
 	return defaultValue
 }
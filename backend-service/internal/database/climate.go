@@ -0,0 +1,48 @@
+package database
+
+import (
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// speciesClimate is the growing climate of a seeded species
+type speciesClimate struct {
+	tempMinC, tempMaxC           float64
+	rainfallMinMm, rainfallMaxMm int
+	latitudeBelt                 float64
+}
+
+// SeedSpeciesClimate fills the growing climate of the seeded species
+// where it has not been set yet
+func SeedSpeciesClimate(db *gorm.DB) error {
+	climates := map[string]speciesClimate{
+		"arabica":  {15, 24, 1200, 2200, 25},
+		"robusta":  {22, 30, 2000, 3000, 20},
+		"liberica": {24, 30, 1500, 2500, 15},
+		"excelsa":  {24, 30, 1500, 2500, 15},
+	}
+
+	var seeded int64
+	for species, c := range climates {
+		result := db.Model(&models.SpeciesOrigin{}).
+			Where("species = ? AND temp_min_c IS NULL", species).
+			UpdateColumns(map[string]interface{}{
+				"temp_min_c":      c.tempMinC,
+				"temp_max_c":      c.tempMaxC,
+				"rainfall_min_mm": c.rainfallMinMm,
+				"rainfall_max_mm": c.rainfallMaxMm,
+				"latitude_belt":   c.latitudeBelt,
+			})
+		if result.Error != nil {
+			log.Error().Err(result.Error).Str("species", species).Msg("Failed to seed species climate")
+			return result.Error
+		}
+		seeded += result.RowsAffected
+	}
+
+	if seeded > 0 {
+		log.Info().Int64("count", seeded).Msg("Seeded species growing climate")
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// SeedHarvestWindows seeds the harvest seasons of the main growing regions
+func SeedHarvestWindows(db *gorm.DB) error {
+	log.Info().Msg("Seeding harvest calendar data...")
+
+	var count int64
+	db.Model(&models.HarvestWindow{}).Count(&count)
+	if count > 0 {
+		log.Info().Int64("count", count).Msg("Harvest calendar data already exists, skipping seed")
+		return nil
+	}
+
+	windows := []models.HarvestWindow{
+		{Species: "arabica", Country: "Ethiopia", Region: "Kaffa Province", Crop: models.CropMain, StartMonth: 10, EndMonth: 1},
+		{Species: "arabica", Country: "Kenya", Region: "Nyeri", Crop: models.CropMain, StartMonth: 10, EndMonth: 12},
+		{Species: "arabica", Country: "Kenya", Region: "Nyeri", Crop: models.CropFly, StartMonth: 5, EndMonth: 7},
+		{Species: "arabica", Country: "Colombia", Region: "Huila", Crop: models.CropMain, StartMonth: 10, EndMonth: 1},
+		{Species: "arabica", Country: "Colombia", Region: "Huila", Crop: models.CropFly, StartMonth: 4, EndMonth: 6},
+		{Species: "arabica", Country: "Indonesia", Region: "Aceh", Crop: models.CropMain, StartMonth: 10, EndMonth: 3},
+		{Species: "robusta", Country: "Vietnam", Region: "Central Highlands", Crop: models.CropMain, StartMonth: 10, EndMonth: 1},
+		{Species: "robusta", Country: "Indonesia", Region: "Lampung", Crop: models.CropMain, StartMonth: 5, EndMonth: 8},
+		{Species: "robusta", Country: "Uganda", Region: "Central", Crop: models.CropMain, StartMonth: 10, EndMonth: 2},
+		{Species: "robusta", Country: "Uganda", Region: "Central", Crop: models.CropFly, StartMonth: 5, EndMonth: 8},
+		{Species: "liberica", Country: "Philippines", Region: "Batangas", Crop: models.CropMain, StartMonth: 11, EndMonth: 3},
+		{Species: "liberica", Country: "Malaysia", Region: "Johor", Crop: models.CropMain, StartMonth: 5, EndMonth: 8},
+		{Species: "excelsa", Country: "Philippines", Region: "Southeast Asia", Crop: models.CropMain, StartMonth: 10, EndMonth: 2},
+	}
+
+	if err := db.Create(&windows).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to seed harvest calendar")
+		return err
+	}
+
+	log.Info().Int("count", len(windows)).Msg("Harvest calendar data seeded successfully")
+	return nil
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var (
+	listenersMu sync.RWMutex
+	listeners   = map[string][]func(){}
+)
+
+// OnTableChange registers fn to be called after every successful create,
+// update or delete issued through GORM against the given table. Writes
+// made inside a transaction are reported once it commits and not at all
+// when it rolls back. Raw SQL is only reported when the statement names
+// its table, as in db.Table("species_origins").Exec(...).
+// Callbacks run synchronously on the writing goroutine and must be cheap.
+func OnTableChange(table string, fn func()) {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	listeners[table] = append(listeners[table], fn)
+}
+
+// registerChangeCallbacks wires the table change listeners into GORM
+func registerChangeCallbacks(db *gorm.DB) error {
+	// Transactions begun through the pool defer notifications to commit
+	db.ConnPool = &changePool{ConnPool: db.ConnPool}
+	db.Statement.ConnPool = db.ConnPool
+
+	if err := db.Callback().Create().After("gorm:create").Register("beanspect:notify_create", notifyChange); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("beanspect:notify_update", notifyChange); err != nil {
+		return err
+	}
+	if err := db.Callback().Raw().After("gorm:raw").Register("beanspect:notify_raw", notifyChange); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register("beanspect:notify_delete", notifyChange)
+}
+
+func notifyChange(tx *gorm.DB) {
+	if tx.Error != nil || tx.Statement == nil || tx.Statement.Table == "" {
+		return
+	}
+
+	if pending, ok := tx.Statement.ConnPool.(*changeTx); ok {
+		pending.queue(tx.Statement.Table)
+		return
+	}
+	notify(tx.Statement.Table)
+}
+
+func notify(table string) {
+	listenersMu.RLock()
+	fns := listeners[table]
+	listenersMu.RUnlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// changePool is the connection pool of the database, beginning
+// transactions that collect the tables they write to
+type changePool struct {
+	gorm.ConnPool
+}
+
+// BeginTx implements gorm.ConnPoolBeginner
+func (p *changePool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	beginner, ok := p.ConnPool.(gorm.TxBeginner)
+	if !ok {
+		return nil, gorm.ErrInvalidTransaction
+	}
+	tx, err := beginner.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &changeTx{Tx: tx, pool: p}, nil
+}
+
+// GetDBConn implements gorm.GetDBConnector, so db.DB() sees through the pool
+func (p *changePool) GetDBConn() (*sql.DB, error) {
+	if sqlDB, ok := p.ConnPool.(*sql.DB); ok {
+		return sqlDB, nil
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+// changeTx is a transaction that notifies the listeners of the tables it
+// wrote to after it commits, so they never observe uncommitted rows
+type changeTx struct {
+	*sql.Tx
+	pool *changePool
+
+	mu     sync.Mutex
+	tables []string
+}
+
+func (t *changeTx) queue(table string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, queued := range t.tables {
+		if queued == table {
+			return
+		}
+	}
+	t.tables = append(t.tables, table)
+}
+
+// Commit implements gorm.TxCommitter
+func (t *changeTx) Commit() error {
+	if err := t.Tx.Commit(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	tables := t.tables
+	t.tables = nil
+	t.mu.Unlock()
+
+	for _, table := range tables {
+		notify(table)
+	}
+	return nil
+}
+
+// GetDBConn implements gorm.GetDBConnector
+func (t *changeTx) GetDBConn() (*sql.DB, error) {
+	return t.pool.GetDBConn()
+}
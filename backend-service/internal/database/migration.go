@@ -12,6 +12,19 @@ func Migrate(db *gorm.DB) error {
 
 	err := db.AutoMigrate(
 		&models.SpeciesOrigin{},
+		&models.SpeciesTranslation{},
+		&models.FlavorTag{},
+		&models.SensoryProfile{},
+		&models.Analysis{},
+		&models.CountryProduction{},
+		&models.HarvestWindow{},
+		&models.APIKey{},
+		&models.User{},
+		&models.RefreshToken{},
+		&models.AuditLog{},
+		&models.RateLimitBucket{},
+		&models.UsageDaily{},
+		&models.UsageMonthly{},
 	)
 	if err != nil {
 		return err
@@ -21,6 +34,39 @@ func Migrate(db *gorm.DB) error {
 	return nil
 }
 
+// BackfillRanges parses the caffeine and altitude strings of rows that
+// predate the numeric range columns and logs rows that fail to parse
+func BackfillRanges(db *gorm.DB) error {
+	var origins []models.SpeciesOrigin
+	err := db.Where("caffeine_min_pct IS NULL AND altitude_min_m IS NULL AND (range_parse_error IS NULL OR range_parse_error = '')").
+		Find(&origins).Error
+	if err != nil {
+		return err
+	}
+
+	for _, o := range origins {
+		o.ParseRanges()
+		err := db.Model(&models.SpeciesOrigin{}).Where("id = ?", o.ID).UpdateColumns(map[string]interface{}{
+			"caffeine_min_pct":  o.CaffeineMinPct,
+			"caffeine_max_pct":  o.CaffeineMaxPct,
+			"altitude_min_m":    o.AltitudeMinM,
+			"altitude_max_m":    o.AltitudeMaxM,
+			"range_parse_error": o.RangeParseError,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if o.RangeParseError != "" {
+			log.Warn().Str("species", o.Species).Str("error", o.RangeParseError).Msg("Failed to parse species ranges")
+		}
+	}
+
+	if len(origins) > 0 {
+		log.Info().Int("count", len(origins)).Msg("Backfilled species caffeine and altitude ranges")
+	}
+	return nil
+}
+
 // SeedSpeciesOrigins seeds the initial coffee species data
 func SeedSpeciesOrigins(db *gorm.DB) error {
 	log.Info().Msg("Seeding species origins data...")
@@ -103,3 +149,38 @@ func SeedSpeciesOrigins(db *gorm.DB) error {
 	log.Info().Msg("Species origins data seeded successfully")
 	return nil
 }
+
+// SeedSpeciesTranslations seeds Indonesian translations of the species content
+func SeedSpeciesTranslations(db *gorm.DB) error {
+	log.Info().Msg("Seeding species translations data...")
+
+	var count int64
+	db.Model(&models.SpeciesTranslation{}).Count(&count)
+	if count > 0 {
+		log.Info().Int64("count", count).Msg("Species translations data already exists, skipping seed")
+		return nil
+	}
+
+	translations := []models.SpeciesTranslation{
+		{Species: "arabica", Field: models.FieldCommonName, Locale: "id", Value: "Kopi Arabika"},
+		{Species: "arabica", Field: models.FieldDescription, Locale: "id", Value: "Kopi arabika dianggap sebagai spesies kopi paling unggul. Berasal dari dataran tinggi Etiopia dan dikenal dengan profil rasa yang lembut dan kompleks dengan nuansa buah, beri, dan keasaman menyerupai anggur."},
+		{Species: "arabica", Field: models.FieldTasteProfile, Locale: "id", Value: "Manis, lembut, fruity dengan nuansa beri, cokelat, dan karamel. Keasaman kompleks mulai dari sitrus hingga menyerupai anggur."},
+		{Species: "robusta", Field: models.FieldCommonName, Locale: "id", Value: "Kopi Robusta"},
+		{Species: "robusta", Field: models.FieldDescription, Locale: "id", Value: "Kopi robusta dikenal dengan rasa yang kuat dan tegas serta kandungan kafein tinggi. Awalnya berasal dari Afrika bagian tengah dan barat, kini terutama ditanam di Vietnam dan Indonesia."},
+		{Species: "robusta", Field: models.FieldTasteProfile, Locale: "id", Value: "Kuat, tegas, earthy dengan nuansa cokelat hitam, kacang, dan biji-bijian. Keasaman rendah dengan body tebal."},
+		{Species: "liberica", Field: models.FieldCommonName, Locale: "id", Value: "Kopi Liberika"},
+		{Species: "liberica", Field: models.FieldDescription, Locale: "id", Value: "Kopi liberika memiliki biji besar berbentuk tidak beraturan dengan aroma yang khas. Berasal dari Liberia, Afrika Barat, kini terutama ditanam di Filipina dan Malaysia. Dikenal secara lokal sebagai 'Kapeng Barako'."},
+		{Species: "liberica", Field: models.FieldTasteProfile, Locale: "id", Value: "Tegas, smoky, woody dengan nuansa bunga dan buah. Aroma unik yang digambarkan mirip nangka."},
+		{Species: "excelsa", Field: models.FieldCommonName, Locale: "id", Value: "Kopi Excelsa"},
+		{Species: "excelsa", Field: models.FieldDescription, Locale: "id", Value: "Kopi excelsa adalah varietas langka yang sering digolongkan sebagai varian liberika. Memiliki profil rasa asam, fruity, dan misterius yang khas. Terutama ditanam di Asia Tenggara."},
+		{Species: "excelsa", Field: models.FieldTasteProfile, Locale: "id", Value: "Asam, fruity, kompleks dengan nuansa sangrai gelap. Memiliki aftertaste menyerupai anggur, popcorn, atau buah."},
+	}
+
+	if err := db.Create(&translations).Error; err != nil {
+		log.Error().Err(err).Msg("Failed to seed species translations")
+		return err
+	}
+
+	log.Info().Int("count", len(translations)).Msg("Species translations data seeded successfully")
+	return nil
+}
@@ -1,9 +1,11 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/beanspect/backend-service/internal/config"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/rs/zerolog/log"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -47,10 +49,24 @@ func Connect(cfg *config.Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err := registerChangeCallbacks(db); err != nil {
+		return nil, fmt.Errorf("failed to register change callbacks: %w", err)
+	}
+
 	log.Info().Msg("Connected to PostgreSQL successfully")
 	return db, nil
 }
 
+// Use makes conn the database connection, for connections opened
+// elsewhere than Connect such as an in-memory database in tests
+func Use(conn *gorm.DB) error {
+	if err := registerChangeCallbacks(conn); err != nil {
+		return fmt.Errorf("failed to register change callbacks: %w", err)
+	}
+	db = conn
+	return nil
+}
+
 // Get returns the database connection
 func Get() *gorm.DB {
 	return db
@@ -67,3 +83,10 @@ func Close() error {
 	}
 	return nil
 }
+
+// IsUniqueViolation reports whether err is PostgreSQL rejecting a row that
+// duplicates a unique key, as when two requests insert it concurrently
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
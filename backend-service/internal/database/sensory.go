@@ -0,0 +1,95 @@
+package database
+
+import (
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/beanspect/backend-service/internal/sensory"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// SeedFlavorWheel seeds the SCA flavor wheel hierarchy into flavor_tags
+func SeedFlavorWheel(db *gorm.DB) error {
+	log.Info().Msg("Seeding flavor wheel data...")
+
+	var count int64
+	db.Model(&models.FlavorTag{}).Count(&count)
+	if count > 0 {
+		log.Info().Int64("count", count).Msg("Flavor wheel data already exists, skipping seed")
+		return nil
+	}
+
+	var seed func(tx *gorm.DB, nodes []sensory.WheelNode, parentID *uint, level int) error
+	seed = func(tx *gorm.DB, nodes []sensory.WheelNode, parentID *uint, level int) error {
+		for _, n := range nodes {
+			tag := models.FlavorTag{Slug: n.Slug, Name: n.Name, ParentID: parentID, Level: level}
+			if err := tx.Create(&tag).Error; err != nil {
+				log.Error().Err(err).Str("slug", n.Slug).Msg("Failed to seed flavor tag")
+				return err
+			}
+			if err := seed(tx, n.Children, &tag.ID, level+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return seed(tx, sensory.Wheel, nil, 1)
+	}); err != nil {
+		return err
+	}
+
+	log.Info().Msg("Flavor wheel data seeded successfully")
+	return nil
+}
+
+// SeedSensoryProfiles seeds the structured taste profiles of the catalog species
+func SeedSensoryProfiles(db *gorm.DB) error {
+	log.Info().Msg("Seeding sensory profiles data...")
+
+	var count int64
+	db.Model(&models.SensoryProfile{}).Count(&count)
+	if count > 0 {
+		log.Info().Int64("count", count).Msg("Sensory profiles data already exists, skipping seed")
+		return nil
+	}
+
+	profiles := []struct {
+		profile models.SensoryProfile
+		tags    []string
+	}{
+		{
+			profile: models.SensoryProfile{Species: "arabica", Acidity: 7.5, Body: 5.0, Sweetness: 7.0, Bitterness: 3.0},
+			tags:    []string{"berry", "chocolate", "caramelized", "citrus_fruit", "winey"},
+		},
+		{
+			profile: models.SensoryProfile{Species: "robusta", Acidity: 2.5, Body: 8.5, Sweetness: 3.0, Bitterness: 8.0},
+			tags:    []string{"dark_chocolate", "nutty", "grain", "musty_earthy"},
+		},
+		{
+			profile: models.SensoryProfile{Species: "liberica", Acidity: 3.5, Body: 7.5, Sweetness: 4.5, Bitterness: 6.5},
+			tags:    []string{"smoky", "woody", "floral_notes", "other_fruit"},
+		},
+		{
+			profile: models.SensoryProfile{Species: "excelsa", Acidity: 6.5, Body: 5.5, Sweetness: 5.0, Bitterness: 5.0},
+			tags:    []string{"sour_aromatics", "other_fruit", "winey", "brown_roast", "cereal"},
+		},
+	}
+
+	for _, p := range profiles {
+		var tags []models.FlavorTag
+		if err := db.Where("slug IN ?", p.tags).Find(&tags).Error; err != nil {
+			return err
+		}
+		profile := p.profile
+		profile.FlavorTags = tags
+		if err := db.Create(&profile).Error; err != nil {
+			log.Error().Err(err).Str("species", profile.Species).Msg("Failed to seed sensory profile")
+			return err
+		}
+		log.Info().Str("species", profile.Species).Int("tags", len(tags)).Msg("Seeded sensory profile")
+	}
+
+	log.Info().Msg("Sensory profiles data seeded successfully")
+	return nil
+}
@@ -0,0 +1,448 @@
+// Package dem reads elevations from a digital elevation model stored as a
+// single-band GeoTIFF in geographic (longitude/latitude) coordinates, such
+// as SRTM or Copernicus DEM tiles. Uncompressed and Deflate-compressed
+// strip or tile layouts are supported.
+package dem
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrOutOfBounds is returned for coordinates outside the raster
+	ErrOutOfBounds = errors.New("coordinates are outside the elevation model")
+	// ErrNoData is returned where the raster holds its nodata value
+	ErrNoData = errors.New("no elevation data at coordinates")
+)
+
+// TIFF and GeoTIFF tags
+const (
+	tagImageWidth       = 256
+	tagImageLength      = 257
+	tagBitsPerSample    = 258
+	tagCompression      = 259
+	tagStripOffsets     = 273
+	tagSamplesPerPixel  = 277
+	tagRowsPerStrip     = 278
+	tagStripByteCounts  = 279
+	tagPlanarConfig     = 284
+	tagPredictor        = 317
+	tagTileWidth        = 322
+	tagTileLength       = 323
+	tagTileOffsets      = 324
+	tagTileByteCounts   = 325
+	tagSampleFormat     = 339
+	tagModelPixelScale  = 33550
+	tagModelTiepoint    = 33922
+	tagGeoKeyDirectory  = 34735
+	tagGDALNoData       = 42113
+	keyGTModelType      = 1024
+	modelTypeGeographic = 2
+)
+
+// Supported encodings
+const (
+	compressionNone       = 1
+	compressionDeflate    = 8
+	compressionDeflateOld = 32946
+	predictorNone         = 1
+	predictorHorizontal   = 2
+	sampleFormatUint      = 1
+	sampleFormatInt       = 2
+	sampleFormatFloat     = 3
+)
+
+// TIFF file structure
+const (
+	tiffClassicVersion = 42
+	tiffBigTIFFVersion = 43
+	tiffHeaderSize     = 8
+	tiffIFDEntrySize   = 12
+	tiffInlineSize     = 4
+)
+
+// Model is an opened elevation raster
+type Model struct {
+	Path string
+
+	file   *os.File
+	order  binary.ByteOrder
+	width  int
+	height int
+
+	// Block layout; strips are blocks spanning the full width
+	blockWidth  int
+	blockHeight int
+	offsets     []uint64
+	byteCounts  []uint64
+
+	bitsPerSample int
+	sampleFormat  int
+	compression   int
+	predictor     int
+
+	// Georeferencing: pixel (0,0) corner and pixel size in degrees
+	originLng, originLat float64
+	scaleLng, scaleLat   float64
+
+	noData    float64
+	hasNoData bool
+
+	mu         sync.Mutex
+	cacheIndex int
+	cacheBlock []byte
+}
+
+// Open opens a GeoTIFF DEM and reads its layout and georeferencing
+func Open(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Model{Path: path, file: f, cacheIndex: -1}
+	if err := m.readHeader(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read GeoTIFF %s: %w", path, err)
+	}
+
+	log.Info().
+		Str("path", path).
+		Int("width", m.width).
+		Int("height", m.height).
+		Float64("pixel_deg", m.scaleLng).
+		Msg("Opened elevation model")
+	return m, nil
+}
+
+// Close releases the underlying file
+func (m *Model) Close() error {
+	return m.file.Close()
+}
+
+// Elevation returns the elevation in metres of the pixel containing the
+// coordinates
+func (m *Model) Elevation(lat, lng float64) (float64, error) {
+	col := int(math.Floor((lng - m.originLng) / m.scaleLng))
+	row := int(math.Floor((m.originLat - lat) / m.scaleLat))
+	if col < 0 || row < 0 || col >= m.width || row >= m.height {
+		return 0, ErrOutOfBounds
+	}
+
+	blocksAcross := (m.width + m.blockWidth - 1) / m.blockWidth
+	index := (row/m.blockHeight)*blocksAcross + col/m.blockWidth
+	block, err := m.block(index)
+	if err != nil {
+		return 0, err
+	}
+
+	bytesPerSample := m.bitsPerSample / 8
+	pos := ((row%m.blockHeight)*m.blockWidth + col%m.blockWidth) * bytesPerSample
+	if pos+bytesPerSample > len(block) {
+		return 0, fmt.Errorf("elevation block %d is truncated", index)
+	}
+	v := m.sample(block[pos:])
+	if math.IsNaN(v) || (m.hasNoData && v == m.noData) {
+		return 0, ErrNoData
+	}
+	return v, nil
+}
+
+// block returns the decoded samples of a strip or tile
+func (m *Model) block(index int) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if index == m.cacheIndex {
+		return m.cacheBlock, nil
+	}
+	if index >= len(m.offsets) || index >= len(m.byteCounts) {
+		return nil, fmt.Errorf("elevation block %d is missing", index)
+	}
+
+	raw := make([]byte, m.byteCounts[index])
+	if _, err := m.file.ReadAt(raw, int64(m.offsets[index])); err != nil {
+		return nil, err
+	}
+
+	data := raw
+	switch m.compression {
+	case compressionNone:
+	case compressionDeflate, compressionDeflateOld:
+		zr, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		data, err = io.ReadAll(zr)
+		zr.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if m.predictor == predictorHorizontal {
+		m.undoPredictor(data)
+	}
+
+	m.cacheIndex, m.cacheBlock = index, data
+	return data, nil
+}
+
+// undoPredictor reverses horizontal differencing of integer samples
+func (m *Model) undoPredictor(data []byte) {
+	size := m.bitsPerSample / 8
+	rowBytes := m.blockWidth * size
+	for start := 0; start+rowBytes <= len(data); start += rowBytes {
+		row := data[start : start+rowBytes]
+		for i := size; i < len(row); i += size {
+			switch size {
+			case 1:
+				row[i] += row[i-1]
+			case 2:
+				m.order.PutUint16(row[i:], m.order.Uint16(row[i:])+m.order.Uint16(row[i-2:]))
+			case 4:
+				m.order.PutUint32(row[i:], m.order.Uint32(row[i:])+m.order.Uint32(row[i-4:]))
+			}
+		}
+	}
+}
+
+func (m *Model) sample(b []byte) float64 {
+	switch m.sampleFormat {
+	case sampleFormatFloat:
+		if m.bitsPerSample == 64 {
+			return math.Float64frombits(m.order.Uint64(b))
+		}
+		return float64(math.Float32frombits(m.order.Uint32(b)))
+	case sampleFormatInt:
+		switch m.bitsPerSample {
+		case 8:
+			return float64(int8(b[0]))
+		case 16:
+			return float64(int16(m.order.Uint16(b)))
+		default:
+			return float64(int32(m.order.Uint32(b)))
+		}
+	default:
+		switch m.bitsPerSample {
+		case 8:
+			return float64(b[0])
+		case 16:
+			return float64(m.order.Uint16(b))
+		default:
+			return float64(m.order.Uint32(b))
+		}
+	}
+}
+
+// readHeader parses the first IFD of the file
+func (m *Model) readHeader() error {
+	head := make([]byte, tiffHeaderSize)
+	if _, err := m.file.ReadAt(head, 0); err != nil {
+		return err
+	}
+	switch string(head[:2]) {
+	case "II":
+		m.order = binary.LittleEndian
+	case "MM":
+		m.order = binary.BigEndian
+	default:
+		return errors.New("not a TIFF file")
+	}
+	switch m.order.Uint16(head[2:]) {
+	case tiffClassicVersion:
+	case tiffBigTIFFVersion:
+		return errors.New("BigTIFF is not supported")
+	default:
+		return errors.New("not a TIFF file")
+	}
+
+	tags, err := m.readIFD(int64(m.order.Uint32(head[4:])))
+	if err != nil {
+		return err
+	}
+
+	m.width = int(tags.uint(tagImageWidth, 0))
+	m.height = int(tags.uint(tagImageLength, 0))
+	m.bitsPerSample = int(tags.uint(tagBitsPerSample, 0))
+	m.sampleFormat = int(tags.uint(tagSampleFormat, sampleFormatUint))
+	m.compression = int(tags.uint(tagCompression, compressionNone))
+	m.predictor = int(tags.uint(tagPredictor, predictorNone))
+
+	if m.width <= 0 || m.height <= 0 {
+		return errors.New("missing image dimensions")
+	}
+	if tags.uint(tagSamplesPerPixel, 1) != 1 {
+		return errors.New("only single-band rasters are supported")
+	}
+	switch m.bitsPerSample {
+	case 8, 16, 32:
+	case 64:
+		if m.sampleFormat != sampleFormatFloat {
+			return errors.New("64-bit integer samples are not supported")
+		}
+	default:
+		return fmt.Errorf("unsupported bits per sample %d", m.bitsPerSample)
+	}
+	switch m.compression {
+	case compressionNone, compressionDeflate, compressionDeflateOld:
+	default:
+		return fmt.Errorf("unsupported compression %d, use none or deflate", m.compression)
+	}
+	switch {
+	case m.predictor == predictorNone:
+	case m.predictor == predictorHorizontal && m.sampleFormat != sampleFormatFloat && m.bitsPerSample <= 32:
+	default:
+		return fmt.Errorf("unsupported predictor %d", m.predictor)
+	}
+
+	if _, tiled := tags.ints[tagTileWidth]; tiled {
+		m.blockWidth = int(tags.uint(tagTileWidth, 0))
+		m.blockHeight = int(tags.uint(tagTileLength, 0))
+		m.offsets = tags.ints[tagTileOffsets]
+		m.byteCounts = tags.ints[tagTileByteCounts]
+	} else {
+		m.blockWidth = m.width
+		m.blockHeight = int(tags.uint(tagRowsPerStrip, uint64(m.height)))
+		m.offsets = tags.ints[tagStripOffsets]
+		m.byteCounts = tags.ints[tagStripByteCounts]
+	}
+	if m.blockWidth <= 0 || m.blockHeight <= 0 || len(m.offsets) == 0 || len(m.offsets) != len(m.byteCounts) {
+		return errors.New("invalid strip or tile layout")
+	}
+
+	// Georeferencing
+	if keys := tags.ints[tagGeoKeyDirectory]; len(keys) >= 4 {
+		for i := 4; i+3 < len(keys); i += 4 {
+			if keys[i] == keyGTModelType && keys[i+3] != modelTypeGeographic {
+				return errors.New("elevation model must use geographic (longitude/latitude) coordinates")
+			}
+		}
+	}
+	scale, tie := tags.floats[tagModelPixelScale], tags.floats[tagModelTiepoint]
+	if len(scale) < 2 || len(tie) < 6 || scale[0] <= 0 || scale[1] <= 0 {
+		return errors.New("missing ModelPixelScale or ModelTiepoint georeferencing")
+	}
+	m.scaleLng, m.scaleLat = scale[0], scale[1]
+	m.originLng = tie[3] - tie[0]*m.scaleLng
+	m.originLat = tie[4] + tie[1]*m.scaleLat
+
+	if s := strings.TrimSpace(strings.TrimRight(tags.ascii[tagGDALNoData], "\x00")); s != "" {
+		if v, err := strconv.ParseFloat(s, 64); err == nil {
+			m.noData, m.hasNoData = v, true
+		}
+	}
+	return nil
+}
+
+// tagSet holds the integer, floating point and ASCII values of an IFD
+type tagSet struct {
+	ints   map[uint16][]uint64
+	floats map[uint16][]float64
+	ascii  map[uint16]string
+}
+
+func (t tagSet) uint(tag uint16, def uint64) uint64 {
+	if v := t.ints[tag]; len(v) > 0 {
+		return v[0]
+	}
+	return def
+}
+
+// readIFD reads every entry of the directory at off
+func (m *Model) readIFD(off int64) (tagSet, error) {
+	tags := tagSet{ints: map[uint16][]uint64{}, floats: map[uint16][]float64{}, ascii: map[uint16]string{}}
+
+	countBuf := make([]byte, 2)
+	if _, err := m.file.ReadAt(countBuf, off); err != nil {
+		return tags, err
+	}
+	n := int(m.order.Uint16(countBuf))
+	entries := make([]byte, n*tiffIFDEntrySize)
+	if _, err := m.file.ReadAt(entries, off+2); err != nil {
+		return tags, err
+	}
+
+	for i := 0; i < n; i++ {
+		e := entries[i*tiffIFDEntrySize:]
+		tag := m.order.Uint16(e)
+		typ := m.order.Uint16(e[2:])
+		count := int(m.order.Uint32(e[4:]))
+
+		size := typeSize(typ)
+		if size == 0 || count <= 0 || count > 1<<24 {
+			continue
+		}
+		data := e[8:12]
+		if size*count > tiffInlineSize {
+			data = make([]byte, size*count)
+			if _, err := m.file.ReadAt(data, int64(m.order.Uint32(e[8:]))); err != nil {
+				return tags, err
+			}
+		}
+
+		switch typ {
+		case 2: // ASCII
+			tags.ascii[tag] = string(data[:count])
+		case 1, 6, 7: // BYTE, SBYTE, UNDEFINED
+			for j := 0; j < count; j++ {
+				tags.ints[tag] = append(tags.ints[tag], uint64(data[j]))
+			}
+		case 3, 8: // SHORT, SSHORT
+			for j := 0; j < count; j++ {
+				tags.ints[tag] = append(tags.ints[tag], uint64(m.order.Uint16(data[j*2:])))
+			}
+		case 4, 9: // LONG, SLONG
+			for j := 0; j < count; j++ {
+				tags.ints[tag] = append(tags.ints[tag], uint64(m.order.Uint32(data[j*4:])))
+			}
+		case 16: // LONG8
+			for j := 0; j < count; j++ {
+				tags.ints[tag] = append(tags.ints[tag], m.order.Uint64(data[j*8:]))
+			}
+		case 5: // RATIONAL
+			for j := 0; j < count; j++ {
+				num := m.order.Uint32(data[j*8:])
+				den := m.order.Uint32(data[j*8+4:])
+				if den != 0 {
+					tags.floats[tag] = append(tags.floats[tag], float64(num)/float64(den))
+				}
+			}
+		case 11: // FLOAT
+			for j := 0; j < count; j++ {
+				tags.floats[tag] = append(tags.floats[tag], float64(math.Float32frombits(m.order.Uint32(data[j*4:]))))
+			}
+		case 12: // DOUBLE
+			for j := 0; j < count; j++ {
+				tags.floats[tag] = append(tags.floats[tag], math.Float64frombits(m.order.Uint64(data[j*8:])))
+			}
+		}
+	}
+	return tags, nil
+}
+
+func typeSize(typ uint16) int {
+	switch typ {
+	case 1, 2, 6, 7:
+		return 1
+	case 3, 8:
+		return 2
+	case 4, 9, 11:
+		return 4
+	case 5, 10, 12, 16:
+		return 8
+	default:
+		return 0
+	}
+}
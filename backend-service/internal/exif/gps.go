@@ -0,0 +1,197 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrNoGPS is returned when an image carries no GPS position
+var ErrNoGPS = errors.New("image has no GPS position")
+
+// GPS is the position recorded in an image's EXIF metadata
+type GPS struct {
+	Latitude  float64
+	Longitude float64
+	// AccuracyM is the horizontal positioning error in metres, or 0 when unknown
+	AccuracyM float64
+}
+
+// TIFF tags used to locate and read the GPS IFD
+const (
+	tagGPSIFD         = 0x8825
+	tagGPSLatRef      = 0x0001
+	tagGPSLat         = 0x0002
+	tagGPSLngRef      = 0x0003
+	tagGPSLng         = 0x0004
+	tagGPSHPosError   = 0x001f
+	typeASCII         = 2
+	typeLong          = 4
+	typeRational      = 5
+	jpegSOI           = 0xd8
+	jpegAPP1          = 0xe1
+	jpegSOS           = 0xda
+	maxSegmentsToScan = 32
+)
+
+// ReadGPS extracts the GPS position from a JPEG's EXIF block
+func ReadGPS(data []byte) (*GPS, error) {
+	tiff, err := findTIFF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid TIFF byte order")
+	}
+	r := reader{data: tiff, order: order}
+
+	ifd0, err := r.u32(4)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := r.ifd(ifd0)
+	if err != nil {
+		return nil, err
+	}
+	gpsEntry, ok := entries[tagGPSIFD]
+	if !ok {
+		return nil, ErrNoGPS
+	}
+	gpsEntries, err := r.ifd(gpsEntry.value)
+	if err != nil {
+		return nil, err
+	}
+
+	lat, err := r.coordinate(gpsEntries, tagGPSLat, tagGPSLatRef, "S")
+	if err != nil {
+		return nil, err
+	}
+	lng, err := r.coordinate(gpsEntries, tagGPSLng, tagGPSLngRef, "W")
+	if err != nil {
+		return nil, err
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return nil, fmt.Errorf("GPS position out of range")
+	}
+
+	gps := &GPS{Latitude: lat, Longitude: lng}
+	if e, ok := gpsEntries[tagGPSHPosError]; ok && e.typ == typeRational {
+		if v, err := r.rationals(e.value, 1); err == nil {
+			gps.AccuracyM = v[0]
+		}
+	}
+	return gps, nil
+}
+
+// findTIFF returns the TIFF header and body of a JPEG's Exif APP1 segment
+func findTIFF(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != jpegSOI {
+		return nil, ErrNoGPS
+	}
+	pos := 2
+	for i := 0; i < maxSegmentsToScan && pos+4 <= len(data); i++ {
+		if data[pos] != 0xff {
+			return nil, ErrNoGPS
+		}
+		marker := data[pos+1]
+		if marker == jpegSOS {
+			break
+		}
+		size := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + size
+		if size < 2 || end > len(data) {
+			return nil, fmt.Errorf("truncated JPEG segment")
+		}
+		body := data[pos+4 : end]
+		if marker == jpegAPP1 && bytes.HasPrefix(body, []byte("Exif\x00\x00")) && len(body) >= 14 {
+			return body[6:], nil
+		}
+		pos = end
+	}
+	return nil, ErrNoGPS
+}
+
+type entry struct {
+	typ   uint16
+	count uint32
+	value uint32 // inline value or offset
+}
+
+type reader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+func (r reader) u32(off uint32) (uint32, error) {
+	if uint64(off)+4 > uint64(len(r.data)) {
+		return 0, fmt.Errorf("EXIF offset out of range")
+	}
+	return r.order.Uint32(r.data[off:]), nil
+}
+
+func (r reader) ifd(off uint32) (map[uint16]entry, error) {
+	if uint64(off)+2 > uint64(len(r.data)) {
+		return nil, fmt.Errorf("EXIF offset out of range")
+	}
+	n := uint32(r.order.Uint16(r.data[off:]))
+	if uint64(off)+2+uint64(n)*12 > uint64(len(r.data)) {
+		return nil, fmt.Errorf("truncated EXIF directory")
+	}
+	entries := make(map[uint16]entry, n)
+	for i := uint32(0); i < n; i++ {
+		p := off + 2 + i*12
+		entries[r.order.Uint16(r.data[p:])] = entry{
+			typ:   r.order.Uint16(r.data[p+2:]),
+			count: r.order.Uint32(r.data[p+4:]),
+			value: r.order.Uint32(r.data[p+8:]),
+		}
+	}
+	return entries, nil
+}
+
+func (r reader) rationals(off uint32, n int) ([]float64, error) {
+	if uint64(off)+uint64(n)*8 > uint64(len(r.data)) {
+		return nil, fmt.Errorf("EXIF offset out of range")
+	}
+	out := make([]float64, n)
+	for i := 0; i < n; i++ {
+		p := off + uint32(i)*8
+		num, den := r.order.Uint32(r.data[p:]), r.order.Uint32(r.data[p+4:])
+		if den == 0 {
+			return nil, fmt.Errorf("invalid EXIF rational")
+		}
+		out[i] = float64(num) / float64(den)
+	}
+	return out, nil
+}
+
+// coordinate reads a degrees/minutes/seconds GPS tag and its hemisphere ref
+func (r reader) coordinate(entries map[uint16]entry, tag, refTag uint16, negative string) (float64, error) {
+	e, ok := entries[tag]
+	if !ok || e.typ != typeRational || e.count != 3 {
+		return 0, ErrNoGPS
+	}
+	dms, err := r.rationals(e.value, 3)
+	if err != nil {
+		return 0, err
+	}
+	v := dms[0] + dms[1]/60 + dms[2]/3600
+
+	// A one-character ASCII ref fits inline in the value field
+	if ref, ok := entries[refTag]; ok && ref.typ == typeASCII {
+		var buf [4]byte
+		r.order.PutUint32(buf[:], ref.value)
+		if string(buf[:1]) == negative {
+			v = -v
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,41 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/beanspect/backend-service/internal/models"
+)
+
+// csvEncoder writes one row per origin with a header row
+type csvEncoder struct{}
+
+func (csvEncoder) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (csvEncoder) Extension() string { return "csv" }
+
+func (csvEncoder) Encode(w io.Writer, origins []models.SpeciesOrigin) error {
+	cw := csv.NewWriter(w)
+
+	var header []string
+	for _, a := range attributes(models.SpeciesOrigin{}) {
+		header = append(header, a.Name)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, o := range origins {
+		attrs := attributes(o)
+		row := make([]string, len(attrs))
+		for i, a := range attrs {
+			row[i] = a.Value
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
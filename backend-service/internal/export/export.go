@@ -0,0 +1,119 @@
+package export
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/models"
+)
+
+// Encoder writes species origins in a single file format
+type Encoder interface {
+	// ContentType is the media type of the encoded output
+	ContentType() string
+	// Extension is the file extension used for download filenames
+	Extension() string
+	// Encode writes all origins to w
+	Encode(w io.Writer, origins []models.SpeciesOrigin) error
+}
+
+var (
+	mu       sync.RWMutex
+	encoders = map[string]Encoder{}
+)
+
+// Register makes an encoder available under the given format name.
+// Registering the same name twice replaces the previous encoder.
+func Register(format string, enc Encoder) {
+	mu.Lock()
+	defer mu.Unlock()
+	encoders[format] = enc
+}
+
+// Lookup returns the encoder registered for a format
+func Lookup(format string) (Encoder, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	enc, ok := encoders[format]
+	return enc, ok
+}
+
+// Formats returns the registered format names in sorted order
+func Formats() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	formats := make([]string, 0, len(encoders))
+	for f := range encoders {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func init() {
+	Register("csv", csvEncoder{})
+	Register("kml", kmlEncoder{})
+	Register("gpx", gpxEncoder{})
+	Register("gpkg", gpkgEncoder{})
+}
+
+// attribute is a single named origin attribute rendered as text
+type attribute struct {
+	Name  string
+	Value string
+}
+
+// attributes lists every exported SpeciesOrigin field in a fixed order
+// so all formats carry the same columns
+func attributes(o models.SpeciesOrigin) []attribute {
+	return []attribute{
+		{"id", strconv.FormatUint(uint64(o.ID), 10)},
+		{"species", o.Species},
+		{"common_name", o.CommonName},
+		{"scientific_name", o.ScientificName},
+		{"country", o.Country},
+		{"region", o.Region},
+		{"latitude", formatCoord(o.Latitude)},
+		{"longitude", formatCoord(o.Longitude)},
+		{"description", o.Description},
+		{"taste_profile", o.TasteProfile},
+		{"caffeine_level", o.CaffeineLevel},
+		{"altitude", o.Altitude},
+		{"caffeine_min_pct", formatFloat(o.CaffeineMinPct)},
+		{"caffeine_max_pct", formatFloat(o.CaffeineMaxPct)},
+		{"altitude_min_m", formatInt(o.AltitudeMinM)},
+		{"altitude_max_m", formatInt(o.AltitudeMaxM)},
+		{"range_parse_error", o.RangeParseError},
+		{"temp_min_c", formatFloat(o.TempMinC)},
+		{"temp_max_c", formatFloat(o.TempMaxC)},
+		{"rainfall_min_mm", formatInt(o.RainfallMinMm)},
+		{"rainfall_max_mm", formatInt(o.RainfallMaxMm)},
+		{"latitude_belt", formatFloat(o.LatitudeBelt)},
+		{"image_url", o.ImageURL},
+		{"created_at", o.CreatedAt.UTC().Format(time.RFC3339)},
+		{"updated_at", o.UpdatedAt.UTC().Format(time.RFC3339)},
+	}
+}
+
+func formatCoord(v float64) string {
+	return strconv.FormatFloat(v, 'f', 7, 64)
+}
+
+// formatFloat formats an optional number, leaving it blank when unset
+func formatFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+// formatInt formats an optional integer, leaving it blank when unset
+func formatInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
@@ -0,0 +1,236 @@
+package export
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+// gpkgTable is the feature table name inside the GeoPackage
+const gpkgTable = "species_origins"
+
+// gpkgEncoder writes an OGC GeoPackage 1.3 with a point feature table.
+// Column names are kept within the 10 character dBase limit so the layer
+// can be saved as a Shapefile from QGIS without renaming.
+type gpkgEncoder struct{}
+
+// gpkgColumns maps attribute names to their shapefile-safe column names
+var gpkgColumns = map[string]string{
+	"id":                "origin_id",
+	"species":           "species",
+	"common_name":       "common_nm",
+	"scientific_name":   "sci_name",
+	"country":           "country",
+	"region":            "region",
+	"latitude":          "latitude",
+	"longitude":         "longitude",
+	"description":       "descr",
+	"taste_profile":     "taste",
+	"caffeine_level":    "caffeine",
+	"altitude":          "altitude",
+	"caffeine_min_pct":  "caff_min",
+	"caffeine_max_pct":  "caff_max",
+	"altitude_min_m":    "alt_min_m",
+	"altitude_max_m":    "alt_max_m",
+	"range_parse_error": "range_err",
+	"temp_min_c":        "temp_min",
+	"temp_max_c":        "temp_max",
+	"rainfall_min_mm":   "rain_min",
+	"rainfall_max_mm":   "rain_max",
+	"latitude_belt":     "lat_belt",
+	"image_url":         "image_url",
+	"created_at":        "created",
+	"updated_at":        "updated",
+}
+
+const gpkgSchema = `
+PRAGMA application_id = 1196444487;
+PRAGMA user_version = 10300;
+
+CREATE TABLE gpkg_spatial_ref_sys (
+	srs_name TEXT NOT NULL,
+	srs_id INTEGER NOT NULL PRIMARY KEY,
+	organization TEXT NOT NULL,
+	organization_coordsys_id INTEGER NOT NULL,
+	definition TEXT NOT NULL,
+	description TEXT
+);
+
+CREATE TABLE gpkg_contents (
+	table_name TEXT NOT NULL PRIMARY KEY,
+	data_type TEXT NOT NULL,
+	identifier TEXT UNIQUE,
+	description TEXT DEFAULT '',
+	last_change DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ','now')),
+	min_x DOUBLE,
+	min_y DOUBLE,
+	max_x DOUBLE,
+	max_y DOUBLE,
+	srs_id INTEGER,
+	CONSTRAINT fk_gc_r_srs_id FOREIGN KEY (srs_id) REFERENCES gpkg_spatial_ref_sys(srs_id)
+);
+
+CREATE TABLE gpkg_geometry_columns (
+	table_name TEXT NOT NULL,
+	column_name TEXT NOT NULL,
+	geometry_type_name TEXT NOT NULL,
+	srs_id INTEGER NOT NULL,
+	z TINYINT NOT NULL,
+	m TINYINT NOT NULL,
+	CONSTRAINT pk_geom_cols PRIMARY KEY (table_name, column_name),
+	CONSTRAINT fk_gc_tn FOREIGN KEY (table_name) REFERENCES gpkg_contents(table_name),
+	CONSTRAINT fk_gc_srs FOREIGN KEY (srs_id) REFERENCES gpkg_spatial_ref_sys(srs_id)
+);
+
+INSERT INTO gpkg_spatial_ref_sys VALUES
+	('Undefined cartesian SRS', -1, 'NONE', -1, 'undefined', 'undefined cartesian coordinate reference system'),
+	('Undefined geographic SRS', 0, 'NONE', 0, 'undefined', 'undefined geographic coordinate reference system'),
+	('WGS 84 geodetic', 4326, 'EPSG', 4326, 'GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563,AUTHORITY["EPSG","7030"]],AUTHORITY["EPSG","6326"]],PRIMEM["Greenwich",0,AUTHORITY["EPSG","8901"]],UNIT["degree",0.0174532925199433,AUTHORITY["EPSG","9122"]],AUTHORITY["EPSG","4326"]]', 'longitude/latitude coordinates in decimal degrees on the WGS 84 spheroid');
+
+CREATE TABLE species_origins (
+	fid INTEGER PRIMARY KEY AUTOINCREMENT,
+	geom POINT,
+	origin_id INTEGER,
+	species TEXT,
+	common_nm TEXT,
+	sci_name TEXT,
+	country TEXT,
+	region TEXT,
+	latitude DOUBLE,
+	longitude DOUBLE,
+	descr TEXT,
+	taste TEXT,
+	caffeine TEXT,
+	altitude TEXT,
+	caff_min DOUBLE,
+	caff_max DOUBLE,
+	alt_min_m INTEGER,
+	alt_max_m INTEGER,
+	range_err TEXT,
+	temp_min DOUBLE,
+	temp_max DOUBLE,
+	rain_min INTEGER,
+	rain_max INTEGER,
+	lat_belt DOUBLE,
+	image_url TEXT,
+	created TEXT,
+	updated TEXT
+);
+
+INSERT INTO gpkg_geometry_columns VALUES ('species_origins', 'geom', 'POINT', 4326, 0, 0);
+`
+
+func (gpkgEncoder) ContentType() string { return "application/geopackage+sqlite3" }
+
+func (gpkgEncoder) Extension() string { return "gpkg" }
+
+// Encode builds the GeoPackage in a temporary file, since SQLite cannot
+// write to an arbitrary stream, and then copies it to w
+func (gpkgEncoder) Encode(w io.Writer, origins []models.SpeciesOrigin) error {
+	tmp, err := os.CreateTemp("", "beanspect-*.gpkg")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	path := tmp.Name()
+	tmp.Close()
+	defer os.Remove(path)
+
+	if err := writeGeoPackage(path, origins); err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func writeGeoPackage(path string, origins []models.SpeciesOrigin) error {
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		return fmt.Errorf("failed to open geopackage: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(gpkgSchema); err != nil {
+		return fmt.Errorf("failed to create geopackage schema: %w", err)
+	}
+
+	attrs := attributes(models.SpeciesOrigin{})
+	columns := "geom"
+	placeholders := "?"
+	for _, a := range attrs {
+		columns += ", " + gpkgColumns[a.Name]
+		placeholders += ", ?"
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", gpkgTable, columns, placeholders))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	for _, o := range origins {
+		args := []interface{}{gpkgPoint(o.Longitude, o.Latitude)}
+		args = append(args, o.ID, o.Species, o.CommonName, o.ScientificName, o.Country, o.Region,
+			o.Latitude, o.Longitude, o.Description, o.TasteProfile, o.CaffeineLevel, o.Altitude,
+			o.CaffeineMinPct, o.CaffeineMaxPct, o.AltitudeMinM, o.AltitudeMaxM, o.RangeParseError,
+			o.TempMinC, o.TempMaxC, o.RainfallMinMm, o.RainfallMaxMm, o.LatitudeBelt,
+			o.ImageURL, o.CreatedAt.UTC().Format(time.RFC3339), o.UpdatedAt.UTC().Format(time.RFC3339))
+		if _, err := stmt.Exec(args...); err != nil {
+			return fmt.Errorf("failed to insert origin %s: %w", o.Species, err)
+		}
+
+		minX, minY = math.Min(minX, o.Longitude), math.Min(minY, o.Latitude)
+		maxX, maxY = math.Max(maxX, o.Longitude), math.Max(maxY, o.Latitude)
+	}
+
+	var bounds []interface{}
+	if len(origins) > 0 {
+		bounds = []interface{}{minX, minY, maxX, maxY}
+	} else {
+		bounds = []interface{}{nil, nil, nil, nil}
+	}
+	_, err = tx.Exec(
+		"INSERT INTO gpkg_contents (table_name, data_type, identifier, description, min_x, min_y, max_x, max_y, srs_id) VALUES (?, 'features', ?, ?, ?, ?, ?, ?, 4326)",
+		append([]interface{}{gpkgTable, "BeanSpect species origins", "Coffee species origin locations"}, bounds...)...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write geopackage contents: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// gpkgPoint encodes a point as GeoPackageBinary: a "GP" header with
+// version, flags (little endian, no envelope) and SRS id, followed by WKB
+func gpkgPoint(x, y float64) []byte {
+	buf := make([]byte, 0, 8+21)
+	buf = append(buf, 'G', 'P', 0, 0x01)
+	buf = binary.LittleEndian.AppendUint32(buf, 4326)
+
+	buf = append(buf, 0x01) // WKB little endian
+	buf = binary.LittleEndian.AppendUint32(buf, 1)
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(x))
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(y))
+	return buf
+}
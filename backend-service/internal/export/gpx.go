@@ -0,0 +1,81 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/beanspect/backend-service/internal/models"
+)
+
+// gpxNamespace is the extension namespace carrying origin attributes
+const gpxNamespace = "https://beanspect.app/xmlschemas/gpx/1"
+
+// gpxEncoder writes a GPX 1.1 file with one waypoint per origin
+type gpxEncoder struct{}
+
+type gpxRoot struct {
+	XMLName   xml.Name      `xml:"gpx"`
+	Xmlns     string        `xml:"xmlns,attr"`
+	XmlnsBS   string        `xml:"xmlns:beanspect,attr"`
+	Version   string        `xml:"version,attr"`
+	Creator   string        `xml:"creator,attr"`
+	Waypoints []gpxWaypoint `xml:"wpt"`
+}
+
+type gpxWaypoint struct {
+	Lat        string        `xml:"lat,attr"`
+	Lon        string        `xml:"lon,attr"`
+	Name       string        `xml:"name"`
+	Desc       string        `xml:"desc,omitempty"`
+	Type       string        `xml:"type,omitempty"`
+	Extensions gpxExtensions `xml:"extensions"`
+}
+
+type gpxExtensions struct {
+	Attributes []gpxAttribute
+}
+
+type gpxAttribute struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+func (gpxEncoder) ContentType() string { return "application/gpx+xml" }
+
+func (gpxEncoder) Extension() string { return "gpx" }
+
+func (gpxEncoder) Encode(w io.Writer, origins []models.SpeciesOrigin) error {
+	doc := gpxRoot{
+		Xmlns:   "http://www.topografix.com/GPX/1/1",
+		XmlnsBS: gpxNamespace,
+		Version: "1.1",
+		Creator: "BeanSpect",
+	}
+
+	for _, o := range origins {
+		wpt := gpxWaypoint{
+			Lat:  formatCoord(o.Latitude),
+			Lon:  formatCoord(o.Longitude),
+			Name: o.CommonName,
+			Desc: o.Description,
+			Type: o.Species,
+		}
+		if wpt.Name == "" {
+			wpt.Name = o.Species
+		}
+		for _, a := range attributes(o) {
+			wpt.Extensions.Attributes = append(wpt.Extensions.Attributes, gpxAttribute{
+				XMLName: xml.Name{Local: "beanspect:" + a.Name},
+				Value:   a.Value,
+			})
+		}
+		doc.Waypoints = append(doc.Waypoints, wpt)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
+}
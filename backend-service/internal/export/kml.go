@@ -0,0 +1,77 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/beanspect/backend-service/internal/models"
+)
+
+// kmlEncoder writes a KML document with one placemark per origin
+type kmlEncoder struct{}
+
+type kmlRoot struct {
+	XMLName  xml.Name    `xml:"kml"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name       string         `xml:"name"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	ID           string    `xml:"id,attr"`
+	Name         string    `xml:"name"`
+	Description  string    `xml:"description"`
+	ExtendedData []kmlData `xml:"ExtendedData>Data"`
+	Point        kmlPoint  `xml:"Point"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+func (kmlEncoder) ContentType() string { return "application/vnd.google-earth.kml+xml" }
+
+func (kmlEncoder) Extension() string { return "kml" }
+
+func (kmlEncoder) Encode(w io.Writer, origins []models.SpeciesOrigin) error {
+	doc := kmlRoot{
+		Xmlns:    "http://www.opengis.net/kml/2.2",
+		Document: kmlDocument{Name: "BeanSpect Species Origins"},
+	}
+
+	for _, o := range origins {
+		pm := kmlPlacemark{
+			ID:          fmt.Sprintf("origin-%d", o.ID),
+			Name:        o.CommonName,
+			Description: o.Description,
+			Point: kmlPoint{
+				// KML coordinates are lng,lat[,alt]
+				Coordinates: formatCoord(o.Longitude) + "," + formatCoord(o.Latitude),
+			},
+		}
+		if pm.Name == "" {
+			pm.Name = o.Species
+		}
+		for _, a := range attributes(o) {
+			pm.ExtendedData = append(pm.ExtendedData, kmlData{Name: a.Name, Value: a.Value})
+		}
+		doc.Document.Placemarks = append(doc.Document.Placemarks, pm)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
+}
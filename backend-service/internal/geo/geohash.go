@@ -0,0 +1,127 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MaxGeohashPrecision is the length of geohashes stored for scan
+// locations, roughly 5m cells
+const MaxGeohashPrecision = 9
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash encodes p as a geohash of the given length
+func Geohash(p Point, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLng, maxLng := -180.0, 180.0
+
+	var sb strings.Builder
+	bit, ch := 0, 0
+	even := true
+	for sb.Len() < precision {
+		if even {
+			mid := (minLng + maxLng) / 2
+			if p.Lng >= mid {
+				ch |= 1 << (4 - bit)
+				minLng = mid
+			} else {
+				maxLng = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if p.Lat >= mid {
+				ch |= 1 << (4 - bit)
+				minLat = mid
+			} else {
+				maxLat = mid
+			}
+		}
+		even = !even
+
+		if bit < 4 {
+			bit++
+		} else {
+			sb.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return sb.String()
+}
+
+// GeohashBounds returns the box covered by a geohash cell
+func GeohashBounds(hash string) (BBox, error) {
+	b := BBox{MinLng: -180, MinLat: -90, MaxLng: 180, MaxLat: 90}
+	even := true
+	for i := 0; i < len(hash); i++ {
+		idx := strings.IndexByte(geohashAlphabet, hash[i])
+		if idx < 0 {
+			return BBox{}, fmt.Errorf("invalid geohash character %q", hash[i])
+		}
+		for bit := 4; bit >= 0; bit-- {
+			on := idx&(1<<bit) != 0
+			if even {
+				mid := (b.MinLng + b.MaxLng) / 2
+				if on {
+					b.MinLng = mid
+				} else {
+					b.MaxLng = mid
+				}
+			} else {
+				mid := (b.MinLat + b.MaxLat) / 2
+				if on {
+					b.MinLat = mid
+				} else {
+					b.MaxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return b, nil
+}
+
+// geohashCellWidth returns the longitude span of a geohash cell
+func geohashCellWidth(precision int) float64 {
+	lngBits := (5*precision + 1) / 2
+	return 360 / math.Exp2(float64(lngBits))
+}
+
+// GeohashPrecisionForZoom picks the finest geohash whose cells are still
+// at least minPixels wide on screen at zoom z
+func GeohashPrecisionForZoom(z int, minPixels float64) int {
+	target := DegreesPerPixel(z) * minPixels
+	precision := 1
+	for p := 1; p <= MaxGeohashPrecision; p++ {
+		if geohashCellWidth(p) < target {
+			break
+		}
+		precision = p
+	}
+	return precision
+}
+
+// ParseBBox parses "minLng,minLat,maxLng,maxLat"
+func ParseBBox(s string) (BBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BBox{}, fmt.Errorf("bbox must be minLng,minLat,maxLng,maxLat")
+	}
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		// NaN compares false against every bound checked below
+		if err != nil || math.IsNaN(f) {
+			return BBox{}, fmt.Errorf("bbox must be minLng,minLat,maxLng,maxLat")
+		}
+		v[i] = f
+	}
+	b := BBox{MinLng: v[0], MinLat: v[1], MaxLng: v[2], MaxLat: v[3]}
+	if b.MinLng > b.MaxLng || b.MinLat > b.MaxLat ||
+		b.MinLng < -180 || b.MaxLng > 180 || b.MinLat < -90 || b.MaxLat > 90 {
+		return BBox{}, fmt.Errorf("bbox is out of range")
+	}
+	return b, nil
+}
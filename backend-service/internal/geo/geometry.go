@@ -0,0 +1,169 @@
+package geo
+
+import "math"
+
+// Point is a longitude/latitude pair in WGS84 degrees
+type Point struct {
+	Lng float64
+	Lat float64
+}
+
+// Ring is a closed sequence of points, the last point may repeat the first
+type Ring []Point
+
+// Polygon is an exterior ring followed by zero or more holes
+type Polygon []Ring
+
+// BBox is a longitude/latitude bounding box
+type BBox struct {
+	MinLng float64
+	MinLat float64
+	MaxLng float64
+	MaxLat float64
+}
+
+// Contains reports whether p lies inside the box, edges included
+func (b BBox) Contains(p Point) bool {
+	return p.Lng >= b.MinLng && p.Lng <= b.MaxLng && p.Lat >= b.MinLat && p.Lat <= b.MaxLat
+}
+
+// Intersects reports whether two boxes overlap
+func (b BBox) Intersects(o BBox) bool {
+	return b.MinLng <= o.MaxLng && b.MaxLng >= o.MinLng && b.MinLat <= o.MaxLat && b.MaxLat >= o.MinLat
+}
+
+// Expand grows the box by the given number of degrees on every side
+func (b BBox) Expand(deg float64) BBox {
+	return BBox{
+		MinLng: math.Max(b.MinLng-deg, -180),
+		MinLat: math.Max(b.MinLat-deg, -MaxMercatorLat),
+		MaxLng: math.Min(b.MaxLng+deg, 180),
+		MaxLat: math.Min(b.MaxLat+deg, MaxMercatorLat),
+	}
+}
+
+// PolygonBounds returns the bounding box of a polygon's exterior ring
+func PolygonBounds(p Polygon) BBox {
+	b := BBox{MinLng: math.Inf(1), MinLat: math.Inf(1), MaxLng: math.Inf(-1), MaxLat: math.Inf(-1)}
+	if len(p) == 0 {
+		return b
+	}
+	for _, pt := range p[0] {
+		b.MinLng = math.Min(b.MinLng, pt.Lng)
+		b.MinLat = math.Min(b.MinLat, pt.Lat)
+		b.MaxLng = math.Max(b.MaxLng, pt.Lng)
+		b.MaxLat = math.Max(b.MaxLat, pt.Lat)
+	}
+	return b
+}
+
+// SimplifyRing reduces the number of vertices in a ring using the
+// Douglas-Peucker algorithm. Tolerance is expressed in degrees.
+// Rings that would collapse below four points are returned unchanged.
+func SimplifyRing(r Ring, tolerance float64) Ring {
+	if tolerance <= 0 || len(r) <= 4 {
+		return r
+	}
+
+	keep := make([]bool, len(r))
+	keep[0] = true
+	keep[len(r)-1] = true
+	douglasPeucker(r, 0, len(r)-1, tolerance*tolerance, keep)
+
+	out := make(Ring, 0, len(r))
+	for i, k := range keep {
+		if k {
+			out = append(out, r[i])
+		}
+	}
+	if len(out) < 4 {
+		return r
+	}
+	return out
+}
+
+// SimplifyPolygon simplifies every ring of a polygon, dropping holes that collapse
+func SimplifyPolygon(p Polygon, tolerance float64) Polygon {
+	out := make(Polygon, 0, len(p))
+	for i, r := range p {
+		s := SimplifyRing(r, tolerance)
+		if i > 0 && len(s) < 4 {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// SimplifyPolygons simplifies each polygon of a multipolygon
+func SimplifyPolygons(polys []Polygon, tolerance float64) []Polygon {
+	out := make([]Polygon, len(polys))
+	for i, p := range polys {
+		out[i] = SimplifyPolygon(p, tolerance)
+	}
+	return out
+}
+
+func douglasPeucker(r Ring, first, last int, sqTolerance float64, keep []bool) {
+	maxDist := 0.0
+	index := 0
+	for i := first + 1; i < last; i++ {
+		d := sqSegmentDistance(r[i], r[first], r[last])
+		if d > maxDist {
+			maxDist = d
+			index = i
+		}
+	}
+	if maxDist > sqTolerance {
+		keep[index] = true
+		if index-first > 1 {
+			douglasPeucker(r, first, index, sqTolerance, keep)
+		}
+		if last-index > 1 {
+			douglasPeucker(r, index, last, sqTolerance, keep)
+		}
+	}
+}
+
+// sqSegmentDistance returns the squared planar distance from p to segment a-b
+func sqSegmentDistance(p, a, b Point) float64 {
+	x, y := a.Lng, a.Lat
+	dx, dy := b.Lng-x, b.Lat-y
+	if dx != 0 || dy != 0 {
+		t := ((p.Lng-x)*dx + (p.Lat-y)*dy) / (dx*dx + dy*dy)
+		if t > 1 {
+			x, y = b.Lng, b.Lat
+		} else if t > 0 {
+			x += dx * t
+			y += dy * t
+		}
+	}
+	dx, dy = p.Lng-x, p.Lat-y
+	return dx*dx + dy*dy
+}
+
+// ContainsPoint reports whether p lies inside the polygon, honouring holes
+func (p Polygon) ContainsPoint(pt Point) bool {
+	if len(p) == 0 || !ringContains(p[0], pt) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if ringContains(hole, pt) {
+			return false
+		}
+	}
+	return true
+}
+
+// ringContains is a standard even-odd ray casting test
+func ringContains(r Ring, pt Point) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		a, b := r[i], r[j]
+		if (a.Lat > pt.Lat) != (b.Lat > pt.Lat) &&
+			pt.Lng < (b.Lng-a.Lng)*(pt.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
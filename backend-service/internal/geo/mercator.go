@@ -0,0 +1,52 @@
+package geo
+
+import "math"
+
+// MaxMercatorLat is the latitude limit of the Web Mercator projection
+const MaxMercatorLat = 85.0511287798066
+
+// MaxZoom is the highest zoom level served by the tile endpoints
+const MaxZoom = 22
+
+// ValidTile reports whether z/x/y addresses an existing XYZ tile
+func ValidTile(z, x, y int) bool {
+	if z < 0 || z > MaxZoom {
+		return false
+	}
+	n := 1 << uint(z)
+	return x >= 0 && x < n && y >= 0 && y < n
+}
+
+// TileBounds returns the longitude/latitude box covered by XYZ tile z/x/y
+func TileBounds(z, x, y int) BBox {
+	n := math.Exp2(float64(z))
+	return BBox{
+		MinLng: float64(x)/n*360 - 180,
+		MaxLng: float64(x+1)/n*360 - 180,
+		MinLat: tileLat(float64(y+1), n),
+		MaxLat: tileLat(float64(y), n),
+	}
+}
+
+func tileLat(y, n float64) float64 {
+	return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+}
+
+// ToTilePixel projects p onto tile z/x/y where the tile spans extent units.
+// Points outside the tile yield coordinates outside [0, extent).
+func ToTilePixel(p Point, z, x, y int, extent int) (float64, float64) {
+	lat := math.Max(math.Min(p.Lat, MaxMercatorLat), -MaxMercatorLat)
+	n := math.Exp2(float64(z))
+	sin := math.Sin(lat * math.Pi / 180)
+
+	wx := (p.Lng + 180) / 360 * n
+	wy := (0.5 - math.Log((1+sin)/(1-sin))/(4*math.Pi)) * n
+
+	return (wx - float64(x)) * float64(extent), (wy - float64(y)) * float64(extent)
+}
+
+// DegreesPerPixel approximates the width in degrees of one 256px screen
+// pixel at the given zoom, which is a convenient simplification tolerance
+func DegreesPerPixel(z int) float64 {
+	return 360 / (256 * math.Exp2(float64(z)))
+}
@@ -0,0 +1,312 @@
+// Package geocode resolves coordinates to countries and first-level
+// administrative regions without calling an external service. Boundaries
+// come from Natural Earth 1:10m data embedded in the binary; see
+// cmd/geodata for how they are built.
+package geocode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/geo"
+	"github.com/rs/zerolog/log"
+)
+
+//go:embed data/*.geojson.gz
+var data embed.FS
+
+// Country is a country boundary's identity
+type Country struct {
+	Name  string   `json:"name"`
+	ISOA2 string   `json:"iso_a2"`
+	ISOA3 string   `json:"iso_a3"`
+	Names []string `json:"-"`
+}
+
+// Region is a first-level administrative division such as a province
+type Region struct {
+	Name    string   `json:"name"`
+	Code    string   `json:"code"`
+	Type    string   `json:"type"`
+	Country string   `json:"-"`
+	Names   []string `json:"-"`
+}
+
+// Place is the result of a reverse lookup
+type Place struct {
+	Country *Country `json:"country"`
+	Region  *Region  `json:"region"`
+	// Approximate is set when the point lies just outside every boundary,
+	// typically offshore, and the nearest boundary was used instead
+	Approximate bool    `json:"approximate"`
+	DistanceKm  float64 `json:"distance_km,omitempty"`
+}
+
+// MaxSnapDistance is how far in degrees (about 25km at the equator) a
+// point may lie outside every boundary and still snap to the nearest one
+const MaxSnapDistance = 0.25
+
+// kmPerDegree converts planar degree distances to an approximate length
+const kmPerDegree = 111.32
+
+// CountryShape is a country together with its boundary polygons
+type CountryShape struct {
+	*Country
+	Polygons []geo.Polygon
+}
+
+// Geocoder answers reverse lookups from in-memory boundary indexes
+type Geocoder struct {
+	countries *Index[*Country]
+	regions   *Index[*Region]
+	shapes    []CountryShape
+	byName    map[string]*Country
+	// regionNames lists regions by lowercase country and region name
+	regionNames map[[2]string]*Region
+}
+
+var (
+	instance *Geocoder
+	once     sync.Once
+	loadErr  error
+)
+
+// Load parses the embedded boundaries and builds the spatial indexes.
+// It is safe to call more than once; only the first call does the work.
+func Load() (*Geocoder, error) {
+	once.Do(func() {
+		start := time.Now()
+		g := &Geocoder{}
+
+		countries, err := readFeatures("data/countries.geojson.gz")
+		if err != nil {
+			loadErr = err
+			return
+		}
+		g.countries = NewIndex[*Country]()
+		g.byName = make(map[string]*Country, len(countries))
+		for _, f := range countries {
+			c := &Country{
+				Name:  f.Properties.Name,
+				ISOA2: f.Properties.ISOA2,
+				ISOA3: f.Properties.ISOA3,
+				Names: f.Properties.Names,
+			}
+			polys := f.polygons()
+			g.countries.Add(c, polys)
+			g.shapes = append(g.shapes, CountryShape{Country: c, Polygons: polys})
+			for _, name := range append([]string{c.Name, c.ISOA2, c.ISOA3}, c.Names...) {
+				key := strings.ToLower(name)
+				// Overseas parts share ISO codes with their mainland, which comes first
+				if _, taken := g.byName[key]; name != "" && !taken {
+					g.byName[key] = c
+				}
+			}
+		}
+
+		regions, err := readFeatures("data/admin1.geojson.gz")
+		if err != nil {
+			loadErr = err
+			return
+		}
+		g.regions = NewIndex[*Region]()
+		g.regionNames = make(map[[2]string]*Region, len(regions))
+		for _, f := range regions {
+			r := &Region{
+				Name:    f.Properties.Name,
+				Code:    f.Properties.Code,
+				Type:    f.Properties.Type,
+				Country: f.Properties.Country,
+				Names:   f.Properties.Names,
+			}
+			g.regions.Add(r, f.polygons())
+			for _, name := range append([]string{r.Name, r.Code}, r.Names...) {
+				if name != "" {
+					g.regionNames[[2]string{strings.ToLower(r.Country), strings.ToLower(name)}] = r
+				}
+			}
+		}
+
+		instance = g
+		log.Info().
+			Int("countries", len(countries)).
+			Int("regions", len(regions)).
+			Dur("took", time.Since(start)).
+			Msg("Loaded reverse geocoding boundaries")
+	})
+	return instance, loadErr
+}
+
+// Get returns the loaded geocoder, or nil if Load has not succeeded
+func Get() *Geocoder {
+	return instance
+}
+
+// Reverse returns the country and region containing the coordinates.
+// Points just offshore snap to the nearest region; it returns nil when the
+// point is further than MaxSnapDistance from every boundary.
+func (g *Geocoder) Reverse(lat, lng float64) *Place {
+	p := geo.Point{Lng: lng, Lat: lat}
+	place := &Place{}
+
+	country, hasCountry := g.countries.Lookup(p)
+	region, hasRegion := g.regions.Lookup(p)
+	if !hasCountry && !hasRegion {
+		var dist float64
+		region, dist, hasRegion = g.regions.Nearest(p, MaxSnapDistance)
+		if !hasRegion {
+			country, dist, hasCountry = g.countries.Nearest(p, MaxSnapDistance)
+		}
+		if !hasRegion && !hasCountry {
+			return nil
+		}
+		place.Approximate = true
+		place.DistanceKm = math.Round(dist*kmPerDegree*10) / 10
+	}
+
+	if hasCountry {
+		place.Country = country
+	}
+	if hasRegion {
+		// Simplified country and region borders can disagree near a
+		// frontier; the region's country wins when the country is unknown
+		if place.Country == nil {
+			place.Country = g.byName[strings.ToLower(region.Country)]
+		}
+		// Unnamed minor islands only identify their country
+		if region.Name != "" {
+			place.Region = region
+		}
+	}
+	if place.Country == nil && place.Region == nil {
+		return nil
+	}
+	return place
+}
+
+// Countries returns every country boundary
+func (g *Geocoder) Countries() []CountryShape {
+	return g.shapes
+}
+
+// LookupCountry finds a country by any of its names or ISO codes
+func (g *Geocoder) LookupCountry(name string) *Country {
+	return g.byName[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// KnownRegion reports whether name is a first-level region of the place's
+// country. Informal growing areas such as "Central Highlands" are not.
+func (g *Geocoder) KnownRegion(place *Place, name string) bool {
+	if place == nil || place.Country == nil {
+		return false
+	}
+	key := [2]string{strings.ToLower(place.Country.Name), strings.ToLower(strings.TrimSpace(name))}
+	_, ok := g.regionNames[key]
+	return ok
+}
+
+// MatchesCountry reports whether name refers to the place's country by
+// any of its names or ISO codes
+func (p *Place) MatchesCountry(name string) bool {
+	if p == nil || p.Country == nil {
+		return false
+	}
+	c := p.Country
+	return matches(name, append([]string{c.Name, c.ISOA2, c.ISOA3}, c.Names...))
+}
+
+// MatchesRegion reports whether name refers to the place's region
+func (p *Place) MatchesRegion(name string) bool {
+	if p == nil || p.Region == nil {
+		return false
+	}
+	return matches(name, append([]string{p.Region.Name, p.Region.Code}, p.Region.Names...))
+}
+
+// CountryName returns the country name, or "" when unknown
+func (p *Place) CountryName() string {
+	if p == nil || p.Country == nil {
+		return ""
+	}
+	return p.Country.Name
+}
+
+// RegionName returns the region name, or "" when unknown
+func (p *Place) RegionName() string {
+	if p == nil || p.Region == nil {
+		return ""
+	}
+	return p.Region.Name
+}
+
+func matches(name string, candidates []string) bool {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return false
+	}
+	for _, c := range candidates {
+		if c != "" && strings.EqualFold(name, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// feature is an entry of the embedded boundary files
+type feature struct {
+	Geometry struct {
+		Coordinates [][][][2]float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		Name    string   `json:"name"`
+		ISOA2   string   `json:"iso_a2"`
+		ISOA3   string   `json:"iso_a3"`
+		Country string   `json:"country"`
+		Code    string   `json:"code"`
+		Type    string   `json:"type"`
+		Names   []string `json:"names"`
+	} `json:"properties"`
+}
+
+func (f feature) polygons() []geo.Polygon {
+	polys := make([]geo.Polygon, len(f.Geometry.Coordinates))
+	for i, rings := range f.Geometry.Coordinates {
+		poly := make(geo.Polygon, len(rings))
+		for j, ring := range rings {
+			r := make(geo.Ring, len(ring))
+			for k, c := range ring {
+				r[k] = geo.Point{Lng: c[0], Lat: c[1]}
+			}
+			poly[j] = r
+		}
+		polys[i] = poly
+	}
+	return polys
+}
+
+func readFeatures(name string) ([]feature, error) {
+	raw, err := data.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	defer zr.Close()
+
+	var fc struct {
+		Features []feature `json:"features"`
+	}
+	if err := json.NewDecoder(zr).Decode(&fc); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return fc.Features, nil
+}
@@ -0,0 +1,114 @@
+package geocode
+
+import (
+	"math"
+
+	"github.com/beanspect/backend-service/internal/geo"
+)
+
+// cellSize is the side of a grid index cell in degrees
+const cellSize = 1.0
+
+// Index is a uniform grid over polygon bounding boxes. Each polygon is
+// listed in every cell its bounds touch, so a lookup only tests the
+// polygons registered in the point's cell.
+type Index[T any] struct {
+	cells map[[2]int][]int
+	parts []part[T]
+}
+
+type part[T any] struct {
+	value   T
+	polygon geo.Polygon
+	bounds  geo.BBox
+}
+
+// NewIndex creates an empty index
+func NewIndex[T any]() *Index[T] {
+	return &Index[T]{cells: make(map[[2]int][]int)}
+}
+
+// Add registers the polygons of one feature under value
+func (idx *Index[T]) Add(value T, polygons []geo.Polygon) {
+	for _, poly := range polygons {
+		if len(poly) == 0 || len(poly[0]) < 4 {
+			continue
+		}
+		b := geo.PolygonBounds(poly)
+		id := len(idx.parts)
+		idx.parts = append(idx.parts, part[T]{value: value, polygon: poly, bounds: b})
+
+		minX, minY := cell(b.MinLng), cell(b.MinLat)
+		maxX, maxY := cell(b.MaxLng), cell(b.MaxLat)
+		for x := minX; x <= maxX; x++ {
+			for y := minY; y <= maxY; y++ {
+				key := [2]int{x, y}
+				idx.cells[key] = append(idx.cells[key], id)
+			}
+		}
+	}
+}
+
+// Lookup returns the value of the first polygon containing p
+func (idx *Index[T]) Lookup(p geo.Point) (T, bool) {
+	for _, id := range idx.cells[[2]int{cell(p.Lng), cell(p.Lat)}] {
+		pt := idx.parts[id]
+		if pt.bounds.Contains(p) && pt.polygon.ContainsPoint(p) {
+			return pt.value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Nearest returns the value of the polygon whose boundary is closest to p
+// within maxDist degrees, along with that distance
+func (idx *Index[T]) Nearest(p geo.Point, maxDist float64) (T, float64, bool) {
+	var best T
+	bestDist := math.Inf(1)
+	seen := make(map[int]bool)
+
+	minX, minY := cell(p.Lng-maxDist), cell(p.Lat-maxDist)
+	maxX, maxY := cell(p.Lng+maxDist), cell(p.Lat+maxDist)
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			for _, id := range idx.cells[[2]int{x, y}] {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+
+				pt := idx.parts[id]
+				if !pt.bounds.Expand(maxDist).Contains(p) {
+					continue
+				}
+				if d := boundaryDistance(pt.polygon, p); d <= maxDist && d < bestDist {
+					best, bestDist = pt.value, d
+				}
+			}
+		}
+	}
+	return best, bestDist, !math.IsInf(bestDist, 1)
+}
+
+// boundaryDistance is the planar distance in degrees from p to the
+// polygon's exterior ring
+func boundaryDistance(poly geo.Polygon, p geo.Point) float64 {
+	ring := poly[0]
+	best := math.Inf(1)
+	for i := 0; i+1 < len(ring); i++ {
+		a, b := ring[i], ring[i+1]
+		dx, dy := b.Lng-a.Lng, b.Lat-a.Lat
+		t := 0.0
+		if l := dx*dx + dy*dy; l > 0 {
+			t = math.Max(0, math.Min(1, ((p.Lng-a.Lng)*dx+(p.Lat-a.Lat)*dy)/l))
+		}
+		ex, ey := p.Lng-(a.Lng+t*dx), p.Lat-(a.Lat+t*dy)
+		best = math.Min(best, math.Hypot(ex, ey))
+	}
+	return best
+}
+
+func cell(v float64) int {
+	return int(math.Floor(v / cellSize))
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/exif"
+	"github.com/beanspect/backend-service/internal/geo"
+	"github.com/beanspect/backend-service/internal/geocode"
+	"github.com/beanspect/backend-service/internal/middleware"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Heatmap defaults
+const (
+	heatmapDefaultZoom = 2
+	heatmapCellPixels  = 32
+)
+
+// AnalysisHandler handles queries over stored analyses
+type AnalysisHandler struct{}
+
+// NewAnalysisHandler creates a new analysis handler
+func NewAnalysisHandler() *AnalysisHandler {
+	return &AnalysisHandler{}
+}
+
+// LocationData is where an analyzed bean was scanned
+type LocationData struct {
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	AccuracyM *float64 `json:"accuracy_m"`
+	Source    string   `json:"source"`
+	Country   string   `json:"country,omitempty"`
+	Region    string   `json:"region,omitempty"`
+}
+
+// heatmapCell is one aggregated row of the heatmap query
+type heatmapCell struct {
+	Species string
+	Cell    string
+	Count   int64
+}
+
+// GetHeatmap aggregates scan locations into geohash cells per species.
+// The bbox query parameter (minLng,minLat,maxLng,maxLat) limits the area
+// and zoom selects the cell size; species optionally filters by a
+// comma-separated list.
+func (h *AnalysisHandler) GetHeatmap(c *fiber.Ctx) error {
+	bounds := geo.BBox{MinLng: -180, MinLat: -90, MaxLng: 180, MaxLat: 90}
+	if raw := c.Query("bbox"); raw != "" {
+		b, err := geo.ParseBBox(raw)
+		if err != nil {
+			return apperr.BadRequest("INVALID_BBOX", "Invalid bbox: %s", err.Error())
+		}
+		bounds = b
+	}
+
+	zoom := c.QueryInt("zoom", heatmapDefaultZoom)
+	if zoom < 0 || zoom > geo.MaxZoom {
+		return apperr.BadRequest("INVALID_ZOOM", "zoom must be between 0 and %d", geo.MaxZoom)
+	}
+	precision := geo.GeohashPrecisionForZoom(zoom, heatmapCellPixels)
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	query := db.Model(&models.Analysis{}).
+		Select("species, SUBSTR(geohash, 1, ?) AS cell, COUNT(*) AS count", precision).
+		Where("geohash <> ''").
+		Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?", bounds.MinLat, bounds.MaxLat, bounds.MinLng, bounds.MaxLng).
+		Group("species, cell")
+	if list := splitList(c.Query("species")); len(list) > 0 {
+		query = query.Where("species IN ?", list)
+	}
+
+	var cells []heatmapCell
+	if err := query.Scan(&cells).Error; err != nil {
+		return apperr.Internal("HEATMAP_ERROR", "Failed to build scan heatmap").WithCause(err)
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Cell != cells[j].Cell {
+			return cells[i].Cell < cells[j].Cell
+		}
+		return cells[i].Species < cells[j].Species
+	})
+
+	var total int64
+	features := make([]fiber.Map, 0, len(cells))
+	for _, cell := range cells {
+		b, err := geo.GeohashBounds(cell.Cell)
+		if err != nil {
+			continue
+		}
+		total += cell.Count
+		features = append(features, fiber.Map{
+			"type": "Feature",
+			"id":   cell.Cell + ":" + cell.Species,
+			"geometry": fiber.Map{
+				"type": "Polygon",
+				"coordinates": [][][]float64{{
+					{b.MinLng, b.MinLat},
+					{b.MaxLng, b.MinLat},
+					{b.MaxLng, b.MaxLat},
+					{b.MinLng, b.MaxLat},
+					{b.MinLng, b.MinLat},
+				}},
+			},
+			"properties": fiber.Map{
+				"geohash": cell.Cell,
+				"species": cell.Species,
+				"count":   cell.Count,
+				"center":  []float64{(b.MinLng + b.MaxLng) / 2, (b.MinLat + b.MaxLat) / 2},
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"type":     "FeatureCollection",
+		"features": features,
+		"properties": fiber.Map{
+			"zoom":      zoom,
+			"precision": precision,
+			"bbox":      []float64{bounds.MinLng, bounds.MinLat, bounds.MaxLng, bounds.MaxLat},
+			"total":     total,
+		},
+	})
+}
+
+// scanLocation reads the optional latitude, longitude and accuracy form
+// fields. Without them, and when use_exif is set, the position is taken
+// from the image's EXIF GPS tags. It returns nil when no location is known.
+// Known locations are labelled with their country and region.
+func scanLocation(c *fiber.Ctx, image []byte) (*LocationData, error) {
+	loc, err := readScanLocation(c, image)
+	if loc != nil {
+		if g := geocode.Get(); g != nil {
+			place := g.Reverse(loc.Latitude, loc.Longitude)
+			loc.Country, loc.Region = place.CountryName(), place.RegionName()
+		}
+	}
+	return loc, err
+}
+
+func readScanLocation(c *fiber.Ctx, image []byte) (*LocationData, error) {
+	latRaw, lngRaw := c.FormValue("latitude"), c.FormValue("longitude")
+	if latRaw != "" || lngRaw != "" {
+		lat, err := strconv.ParseFloat(latRaw, 64)
+		if err != nil || math.IsNaN(lat) || lat < -90 || lat > 90 {
+			return nil, fmt.Errorf("latitude must be a number between -90 and 90")
+		}
+		lng, err := strconv.ParseFloat(lngRaw, 64)
+		if err != nil || math.IsNaN(lng) || lng < -180 || lng > 180 {
+			return nil, fmt.Errorf("longitude must be a number between -180 and 180")
+		}
+		loc := &LocationData{Latitude: lat, Longitude: lng, Source: models.LocationSourceClient}
+		if raw := c.FormValue("accuracy"); raw != "" {
+			acc, err := strconv.ParseFloat(raw, 64)
+			if err != nil || math.IsNaN(acc) || math.IsInf(acc, 0) || acc < 0 {
+				return nil, fmt.Errorf("accuracy must be a non-negative number of metres")
+			}
+			loc.AccuracyM = &acc
+		}
+		return loc, nil
+	}
+
+	if useEXIF, _ := strconv.ParseBool(c.FormValue("use_exif", c.Query("use_exif"))); !useEXIF {
+		return nil, nil
+	}
+	gps, err := exif.ReadGPS(image)
+	if err != nil {
+		if !errors.Is(err, exif.ErrNoGPS) {
+			middleware.Log(c).Warn().Err(err).Msg("Failed to read EXIF GPS position")
+		}
+		return nil, nil
+	}
+	loc := &LocationData{Latitude: gps.Latitude, Longitude: gps.Longitude, Source: models.LocationSourceEXIF}
+	if gps.AccuracyM > 0 {
+		loc.AccuracyM = &gps.AccuracyM
+	}
+	return loc, nil
+}
+
+// newAnalysis builds the stored record of an analysis
+func newAnalysis(species string, confidence float64, loc *LocationData) *models.Analysis {
+	a := &models.Analysis{Species: species, Confidence: confidence}
+	if loc != nil {
+		a.Latitude = &loc.Latitude
+		a.Longitude = &loc.Longitude
+		a.AccuracyM = loc.AccuracyM
+		a.LocationSource = loc.Source
+		a.Geohash = geo.Geohash(geo.Point{Lng: loc.Longitude, Lat: loc.Latitude}, geo.MaxGeohashPrecision)
+		a.Country = loc.Country
+		a.Region = loc.Region
+	}
+	return a
+}
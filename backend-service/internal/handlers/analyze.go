@@ -1,13 +1,21 @@
 package handlers
 
 import (
-	"io"
+	"sort"
+	"strconv"
 
+	"github.com/beanspect/backend-service/internal/apperr"
 	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/middleware"
 	"github.com/beanspect/backend-service/internal/models"
+	"github.com/beanspect/backend-service/internal/recommend"
 	"github.com/beanspect/backend-service/internal/services"
+	"github.com/beanspect/backend-service/internal/tracing"
 	"github.com/gofiber/fiber/v2"
-	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 )
 
 // AnalyzeHandler handles the combined analyze requests
@@ -24,8 +32,20 @@ func NewAnalyzeHandler() *AnalyzeHandler {
 
 // AnalyzeResponse represents the combined response
 type AnalyzeResponse struct {
-	Prediction PredictionData `json:"prediction"`
-	Origin     *OriginData    `json:"origin"`
+	Prediction PredictionData    `json:"prediction"`
+	Origin     *OriginData       `json:"origin"`
+	Sensory    *SensoryData      `json:"sensory"`
+	Similar    []recommend.Match `json:"similar,omitempty"`
+	Candidates []CandidateData   `json:"candidates,omitempty"`
+	AnalysisID *uint             `json:"analysis_id,omitempty"`
+	Location   *LocationData     `json:"location,omitempty"`
+}
+
+// CandidateData is an alternative prediction paired with its origin
+type CandidateData struct {
+	Species    string      `json:"species"`
+	Confidence float64     `json:"confidence"`
+	Origin     *OriginData `json:"origin"`
 }
 
 // PredictionData contains prediction results
@@ -37,71 +57,68 @@ type PredictionData struct {
 
 // OriginData contains species origin information
 type OriginData struct {
-	ID             uint    `json:"id"`
-	Species        string  `json:"species"`
-	CommonName     string  `json:"common_name"`
-	ScientificName string  `json:"scientific_name"`
-	Country        string  `json:"country"`
-	Region         string  `json:"region"`
-	Latitude       float64 `json:"latitude"`
-	Longitude      float64 `json:"longitude"`
-	Description    string  `json:"description"`
-	TasteProfile   string  `json:"taste_profile"`
-	CaffeineLevel  string  `json:"caffeine_level"`
-	Altitude       string  `json:"altitude"`
-	ImageURL       string  `json:"image_url"`
+	ID             uint     `json:"id"`
+	Species        string   `json:"species"`
+	CommonName     string   `json:"common_name"`
+	ScientificName string   `json:"scientific_name"`
+	Country        string   `json:"country"`
+	Region         string   `json:"region"`
+	Latitude       float64  `json:"latitude"`
+	Longitude      float64  `json:"longitude"`
+	Description    string   `json:"description"`
+	TasteProfile   string   `json:"taste_profile"`
+	CaffeineLevel  string   `json:"caffeine_level"`
+	CaffeineMinPct *float64 `json:"caffeine_min_pct"`
+	CaffeineMaxPct *float64 `json:"caffeine_max_pct"`
+	Altitude       string   `json:"altitude"`
+	AltitudeMinM   *int     `json:"altitude_min_m"`
+	AltitudeMaxM   *int     `json:"altitude_max_m"`
+	ImageURL       string   `json:"image_url"`
 }
 
-// Analyze receives an image, gets prediction, and returns combined data with origin
+// similarInAnalysis caps the similar species returned with an analysis
+const similarInAnalysis = 3
+
+// Analyze receives an image, gets prediction, and returns combined data with origin.
+// With similar=true the response also recommends species similar to the prediction.
+// top_k and threshold attach origin data for the alternative predictions: the
+// k most probable classes, those with confidence >= threshold, or both.
+// The optional latitude, longitude and accuracy form fields (or the image's
+// EXIF GPS position with use_exif=true) geotag the stored analysis.
 func (h *AnalyzeHandler) Analyze(c *fiber.Ctx) error {
-	// Step 1: Receive image from frontend
-	file, err := c.FormFile("file")
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get file from form")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   true,
-			"code":    "FILE_REQUIRED",
-			"message": "Image file is required",
-		})
+	topK := c.QueryInt("top_k", 0)
+	if topK < 0 {
+		return apperr.BadRequest("INVALID_TOP_K", "top_k must be a positive integer")
+	}
+	threshold := -1.0
+	if raw := c.Query("threshold"); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 || v > 1 {
+			return apperr.BadRequest("INVALID_THRESHOLD", "threshold must be a number between 0 and 1")
+		}
+		threshold = v
 	}
 
-	// Open file
-	f, err := file.Open()
+	// Step 1: Receive image from frontend
+	file, content, err := readImage(c)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to open file")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   true,
-			"code":    "FILE_OPEN_ERROR",
-			"message": "Failed to open uploaded file",
-		})
+		return err
 	}
-	defer f.Close()
 
-	// Read file content
-	content, err := io.ReadAll(f)
+	middleware.Log(c).Info().Str("filename", file.Filename).Int("size", len(content)).Msg("Received image for analysis")
+
+	location, err := scanLocation(c, content)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to read file")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   true,
-			"code":    "FILE_READ_ERROR",
-			"message": "Failed to read uploaded file",
-		})
+		return apperr.BadRequest("INVALID_LOCATION", "Invalid scan location: %s", err.Error())
 	}
 
-	log.Info().Str("filename", file.Filename).Int("size", len(content)).Msg("Received image for analysis")
-
 	// Step 2 & 3: Forward to inference service and receive prediction
-	prediction, err := h.inferenceClient.Predict(file.Filename, content)
+	prediction, err := h.inferenceClient.Predict(c.UserContext(), file.Filename, content)
 	if err != nil {
-		log.Error().Err(err).Msg("Inference service error")
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-			"error":   true,
-			"code":    "INFERENCE_ERROR",
-			"message": err.Error(),
-		})
+		return inferenceFailure(err)
 	}
 
-	log.Info().
+	middleware.Log(c).Info().
 		Str("species", prediction.PredictedClass).
 		Float64("confidence", prediction.Confidence).
 		Msg("Received prediction from inference service")
@@ -109,32 +126,70 @@ func (h *AnalyzeHandler) Analyze(c *fiber.Ctx) error {
 	// Step 4: Fetch GIS origin data
 	db := database.Get()
 	var origin *OriginData
+	var sensory *SensoryData
+	var similar []recommend.Match
+	var alternatives []CandidateData
+	var analysisID *uint
+
+	candidates := selectCandidates(prediction.AllPredictions, topK, threshold)
 
 	if db != nil {
-		var speciesOrigin models.SpeciesOrigin
-		result := db.Where("species = ?", prediction.PredictedClass).First(&speciesOrigin)
-		if result.Error == nil {
-			origin = &OriginData{
-				ID:             speciesOrigin.ID,
-				Species:        speciesOrigin.Species,
-				CommonName:     speciesOrigin.CommonName,
-				ScientificName: speciesOrigin.ScientificName,
-				Country:        speciesOrigin.Country,
-				Region:         speciesOrigin.Region,
-				Latitude:       speciesOrigin.Latitude,
-				Longitude:      speciesOrigin.Longitude,
-				Description:    speciesOrigin.Description,
-				TasteProfile:   speciesOrigin.TasteProfile,
-				CaffeineLevel:  speciesOrigin.CaffeineLevel,
-				Altitude:       speciesOrigin.Altitude,
-				ImageURL:       speciesOrigin.ImageURL,
+		// Fetch the predicted species and every candidate in one query
+		names := []string{prediction.PredictedClass}
+		for _, cand := range candidates {
+			names = append(names, cand.Class)
+		}
+		found, err := lookupOrigins(c, db, uniqueList(names))
+		if err != nil {
+			middleware.Log(c).Warn().Err(err).Msg("Failed to fetch origin data")
+		}
+		localizeOrigins(c, db, found)
+		origins := make(map[string]models.SpeciesOrigin, len(found))
+		for _, o := range found {
+			origins[o.Species] = o
+		}
+
+		if speciesOrigin, ok := origins[prediction.PredictedClass]; ok {
+			origin = newOriginData(speciesOrigin)
+			middleware.Log(c).Info().Str("species", origin.Species).Str("country", origin.Country).Msg("Fetched origin data")
+
+			profiles, err := loadSensory(db, []models.SpeciesOrigin{speciesOrigin})
+			if err != nil {
+				middleware.Log(c).Warn().Err(err).Str("species", speciesOrigin.Species).Msg("Failed to fetch sensory profile")
+			}
+			sensory = profiles[speciesOrigin.Species]
+
+			if c.QueryBool("similar") {
+				similar, err = findSimilar(c, db, speciesOrigin.Species, similarInAnalysis)
+				if err != nil {
+					middleware.Log(c).Warn().Err(err).Str("species", speciesOrigin.Species).Msg("Failed to rank similar species")
+				}
+			}
+		} else {
+			middleware.Log(c).Warn().Str("species", prediction.PredictedClass).Msg("Origin data not found for species")
+		}
+
+		for _, cand := range candidates {
+			data := CandidateData{Species: cand.Class, Confidence: cand.Confidence}
+			if o, ok := origins[cand.Class]; ok {
+				data.Origin = newOriginData(o)
 			}
-			log.Info().Str("species", origin.Species).Str("country", origin.Country).Msg("Fetched origin data")
+			alternatives = append(alternatives, data)
+		}
+
+		// Record the analysis with its scan location
+		record := newAnalysis(prediction.PredictedClass, prediction.Confidence, location)
+		record.UserID, record.APIKeyID = requestUserID(c), requestAPIKeyID(c)
+		if err := db.Create(record).Error; err != nil {
+			middleware.Log(c).Warn().Err(err).Msg("Failed to store analysis")
 		} else {
-			log.Warn().Str("species", prediction.PredictedClass).Msg("Origin data not found for species")
+			analysisID = &record.ID
 		}
 	} else {
-		log.Warn().Msg("Database not connected, skipping origin data fetch")
+		middleware.Log(c).Warn().Msg("Database not connected, skipping origin data fetch")
+		for _, cand := range candidates {
+			alternatives = append(alternatives, CandidateData{Species: cand.Class, Confidence: cand.Confidence})
+		}
 	}
 
 	// Step 5: Return combined response
@@ -144,9 +199,83 @@ func (h *AnalyzeHandler) Analyze(c *fiber.Ctx) error {
 			Confidence:     prediction.Confidence,
 			AllPredictions: prediction.AllPredictions,
 		},
-		Origin: origin,
+		Origin:     origin,
+		Sensory:    sensory,
+		Similar:    similar,
+		Candidates: alternatives,
+		AnalysisID: analysisID,
+		Location:   location,
 	}
 
-	log.Info().Msg("Analysis complete, returning combined response")
+	middleware.Log(c).Info().Msg("Analysis complete, returning combined response")
 	return c.JSON(response)
 }
+
+// lookupOrigins fetches the origins of species in one query, traced as
+// its own span
+func lookupOrigins(c *fiber.Ctx, db *gorm.DB, species []string) ([]models.SpeciesOrigin, error) {
+	ctx, span := tracing.Tracer().Start(c.UserContext(), "origins.lookup",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName("SELECT"),
+			semconv.DBCollectionName(models.SpeciesOrigin{}.TableName()),
+			attribute.StringSlice("beanspect.species", species),
+		),
+	)
+
+	var found []models.SpeciesOrigin
+	err := db.WithContext(ctx).Where("species IN ?", species).Find(&found).Error
+	if err == nil {
+		span.SetAttributes(attribute.Int("beanspect.origins.found", len(found)))
+	}
+	tracing.End(span, err)
+	return found, err
+}
+
+// newOriginData converts a species origin to its analyze response form
+func newOriginData(o models.SpeciesOrigin) *OriginData {
+	return &OriginData{
+		ID:             o.ID,
+		Species:        o.Species,
+		CommonName:     o.CommonName,
+		ScientificName: o.ScientificName,
+		Country:        o.Country,
+		Region:         o.Region,
+		Latitude:       o.Latitude,
+		Longitude:      o.Longitude,
+		Description:    o.Description,
+		TasteProfile:   o.TasteProfile,
+		CaffeineLevel:  o.CaffeineLevel,
+		CaffeineMinPct: o.CaffeineMinPct,
+		CaffeineMaxPct: o.CaffeineMaxPct,
+		Altitude:       o.Altitude,
+		AltitudeMinM:   o.AltitudeMinM,
+		AltitudeMaxM:   o.AltitudeMaxM,
+		ImageURL:       o.ImageURL,
+	}
+}
+
+// selectCandidates returns the predictions within the top k (when k > 0)
+// and at or above threshold (when threshold >= 0), most probable first.
+// Neither option set means no candidates.
+func selectCandidates(predictions []services.ClassPrediction, k int, threshold float64) []services.ClassPrediction {
+	if k <= 0 && threshold < 0 {
+		return nil
+	}
+
+	sorted := append([]services.ClassPrediction(nil), predictions...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Confidence > sorted[j].Confidence })
+
+	var out []services.ClassPrediction
+	for i, p := range sorted {
+		if k > 0 && i >= k {
+			break
+		}
+		if threshold >= 0 && p.Confidence < threshold {
+			break
+		}
+		out = append(out, p)
+	}
+	return out
+}
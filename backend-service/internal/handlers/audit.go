@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/middleware"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Audited actions
+const (
+	AuditTranslationUpsert = "translation.upsert"
+	AuditTranslationDelete = "translation.delete"
+	AuditOriginsImport     = "origins.import"
+	AuditProductionImport  = "production.import"
+	AuditUserUpdate        = "user.update"
+)
+
+// recordAudit attributes an administrative change to the caller. Failures
+// are logged rather than failing a change that already happened.
+func recordAudit(c *fiber.Ctx, db *gorm.DB, action, target string, detail string, args ...interface{}) {
+	entry := models.AuditLog{
+		UserID: requestUserID(c),
+		Action: action,
+		Target: target,
+		Detail: fmt.Sprintf(detail, args...),
+	}
+	if key := middleware.GetAPIKey(c); key != nil {
+		entry.APIKeyID = &key.ID
+	}
+
+	if err := db.Create(&entry).Error; err != nil {
+		middleware.Log(c).Error().Err(err).Str("action", action).Msg("Failed to record audit log")
+	}
+}
+
+// requestUserID returns the id of the signed-in user, if any
+func requestUserID(c *fiber.Ctx) *uint {
+	if user := middleware.GetUser(c); user != nil {
+		return &user.ID
+	}
+	return nil
+}
+
+// requestAPIKeyID returns the id of the API key the request was made
+// with, if any
+func requestAPIKeyID(c *fiber.Ctx) *uint {
+	if key := middleware.GetAPIKey(c); key != nil {
+		return &key.ID
+	}
+	return nil
+}
+
+// AuditHandler serves the audit log
+type AuditHandler struct{}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{}
+}
+
+// ListAuditLogs returns the most recent audit entries, optionally
+// filtered by the action and user_id query parameters
+func (h *AuditHandler) ListAuditLogs(c *fiber.Ctx) error {
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	limit := c.QueryInt("limit", 100)
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	query := db.Order("created_at DESC, id DESC").Limit(limit)
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if raw := c.Query("user_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return apperr.BadRequest("INVALID_USER_ID", "Invalid user id")
+		}
+		query = query.Where("user_id = ?", id)
+	}
+
+	var entries []models.AuditLog
+	if err := query.Find(&entries).Error; err != nil {
+		return apperr.Internal("AUDIT_FETCH_ERROR", "Failed to fetch audit logs").WithCause(err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  entries,
+		"count": len(entries),
+	})
+}
@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"errors"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/auth"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/middleware"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// AuthHandler handles user registration, login and token refresh
+type AuthHandler struct{}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler() *AuthHandler {
+	return &AuthHandler{}
+}
+
+// RegisterRequest is the body of a registration
+type RegisterRequest struct {
+	Email    string `json:"email"`
+	Name     string `json:"name,omitempty"`
+	Password string `json:"password"`
+}
+
+// LoginRequest is the body of a login
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// RefreshRequest is the body of a token refresh or logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse is returned on login, registration and refresh
+type TokenResponse struct {
+	AccessToken  string       `json:"access_token"`
+	TokenType    string       `json:"token_type"`
+	ExpiresIn    int          `json:"expires_in"` // seconds
+	RefreshToken string       `json:"refresh_token"`
+	User         *models.User `json:"user"`
+}
+
+// Register creates a grader account and signs it in. Admins are appointed
+// by other admins, or with the user command on a fresh install.
+func (h *AuthHandler) Register(c *fiber.Ctx) error {
+	var req RegisterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.BadRequest("INVALID_REQUEST", "Invalid request body")
+	}
+
+	email, ok := normalizeEmail(req.Email)
+	if !ok {
+		return apperr.BadRequest("INVALID_EMAIL", "A valid email address is required")
+	}
+	if err := auth.ValidatePassword(req.Password); err != nil {
+		return apperr.BadRequest("INVALID_PASSWORD", "Invalid password: %s", err.Error())
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return apperr.Internal("REGISTRATION_ERROR", "Failed to register user").WithCause(err)
+	}
+
+	user := models.User{
+		Email:        email,
+		Name:         strings.TrimSpace(req.Name),
+		PasswordHash: hash,
+		Role:         models.RoleGrader,
+	}
+	// The unique index settles concurrent registrations of one email
+	if err := db.Create(&user).Error; err != nil {
+		if database.IsUniqueViolation(err) {
+			return apperr.Conflict("EMAIL_TAKEN", "Email '%s' is already registered", email)
+		}
+		return apperr.Internal("REGISTRATION_ERROR", "Failed to register user").WithCause(err)
+	}
+
+	middleware.Log(c).Info().Uint("user_id", user.ID).Str("role", user.Role).Msg("Registered user")
+
+	tokens, err := issueTokens(c, db, &user)
+	if err != nil {
+		return apperr.Internal("TOKEN_ISSUE_ERROR", "Failed to issue tokens").WithCause(err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": tokens,
+	})
+}
+
+// Login exchanges an email and password for an access and refresh token
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
+	var req LoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.BadRequest("INVALID_REQUEST", "Invalid request body")
+	}
+	email, _ := normalizeEmail(req.Email)
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	var user models.User
+	err := db.Where("email = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		auth.CheckNoUser(req.Password)
+		return apperr.Unauthorized("INVALID_CREDENTIALS", "Invalid email or password")
+	}
+	if err != nil {
+		return apperr.Internal("LOGIN_ERROR", "Failed to sign in").WithCause(err)
+	}
+	if !auth.CheckPassword(user.PasswordHash, req.Password) {
+		return apperr.Unauthorized("INVALID_CREDENTIALS", "Invalid email or password")
+	}
+	if user.Disabled {
+		return apperr.Forbidden("USER_DISABLED", "User account is disabled")
+	}
+
+	now := time.Now()
+	if err := db.Model(&user).UpdateColumn("last_login_at", now).Error; err != nil {
+		middleware.Log(c).Warn().Err(err).Uint("user_id", user.ID).Msg("Failed to record login time")
+	}
+	user.LastLoginAt = &now
+
+	tokens, err := issueTokens(c, db, &user)
+	if err != nil {
+		return apperr.Internal("TOKEN_ISSUE_ERROR", "Failed to issue tokens").WithCause(err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": tokens,
+	})
+}
+
+// Refresh rotates a refresh token, returning a new access and refresh
+// token. Each refresh token can be used once.
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req RefreshRequest
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return apperr.BadRequest("INVALID_REQUEST", "Invalid request body")
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	user, refresh, err := auth.Rotate(db, req.RefreshToken, requestClient(c))
+	switch {
+	case errors.Is(err, auth.ErrInvalidToken):
+		return apperr.Unauthorized("REFRESH_TOKEN_INVALID", "Refresh token is invalid, expired or already used")
+	case errors.Is(err, auth.ErrUserDisabled):
+		return apperr.Forbidden("USER_DISABLED", "User account is disabled")
+	case err != nil:
+		return apperr.Internal("TOKEN_ISSUE_ERROR", "Failed to issue tokens").WithCause(err)
+	}
+
+	access, _, err := auth.IssueAccessToken(user)
+	if err != nil {
+		return apperr.Internal("TOKEN_ISSUE_ERROR", "Failed to issue tokens").WithCause(err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": newTokenResponse(user, access, refresh),
+	})
+}
+
+// Logout revokes the session a refresh token belongs to. Access tokens
+// already issued stay valid until they expire.
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	var req RefreshRequest
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return apperr.BadRequest("INVALID_REQUEST", "Invalid request body")
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	if err := auth.Revoke(db, req.RefreshToken); err != nil && !errors.Is(err, auth.ErrInvalidToken) {
+		return apperr.Internal("LOGOUT_ERROR", "Failed to sign out").WithCause(err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Me returns the signed-in user
+func (h *AuthHandler) Me(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"data": middleware.GetUser(c),
+	})
+}
+
+// UserUpdateRequest is the body of an admin change to a user
+type UserUpdateRequest struct {
+	Role         *string `json:"role"`
+	Disabled     *bool   `json:"disabled"`
+	MonthlyQuota *int    `json:"monthly_quota"` // negative clears the override
+}
+
+// ListUsers returns every user account
+func (h *AuthHandler) ListUsers(c *fiber.Ctx) error {
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	var users []models.User
+	if err := db.Order("id").Find(&users).Error; err != nil {
+		return apperr.Internal("USER_FETCH_ERROR", "Failed to fetch users").WithCause(err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  users,
+		"count": len(users),
+	})
+}
+
+// UpdateUser changes the role, monthly quota or disabled state of a user.
+// A role change or disabling signs the user out of every session.
+func (h *AuthHandler) UpdateUser(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return apperr.BadRequest("INVALID_USER_ID", "Invalid user id")
+	}
+
+	var req UserUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.BadRequest("INVALID_REQUEST", "Invalid request body")
+	}
+	if req.Role != nil && !slices.Contains(models.Roles, *req.Role) {
+		return apperr.BadRequest("INVALID_ROLE", "Invalid role '%s', expected one of: %s", *req.Role, strings.Join(models.Roles, ", "))
+	}
+
+	// Admins cannot lock themselves out
+	if current := middleware.GetUser(c); current != nil && current.ID == uint(id) {
+		if (req.Role != nil && *req.Role != models.RoleAdmin) || (req.Disabled != nil && *req.Disabled) {
+			return apperr.BadRequest("SELF_LOCKOUT", "You cannot demote or disable your own account")
+		}
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	var user models.User
+	if err := db.First(&user, id).Error; err != nil {
+		return apperr.NotFound("USER_NOT_FOUND", "User not found")
+	}
+
+	updates := map[string]interface{}{}
+	if req.Role != nil && *req.Role != user.Role {
+		updates["role"] = *req.Role
+	}
+	if req.Disabled != nil && *req.Disabled != user.Disabled {
+		updates["disabled"] = *req.Disabled
+	}
+	signOut := len(updates) > 0
+	if req.MonthlyQuota != nil {
+		if *req.MonthlyQuota < 0 {
+			updates["monthly_quota"] = nil
+		} else {
+			updates["monthly_quota"] = *req.MonthlyQuota
+		}
+	}
+
+	if len(updates) > 0 {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&user).Updates(updates).Error; err != nil {
+				return err
+			}
+			if !signOut {
+				return nil
+			}
+			return auth.RevokeUser(tx, user.ID)
+		})
+		if err != nil {
+			return apperr.Internal("USER_UPDATE_ERROR", "Failed to update user").WithCause(err)
+		}
+		if req.Role != nil {
+			user.Role = *req.Role
+		}
+		if req.Disabled != nil {
+			user.Disabled = *req.Disabled
+		}
+		if req.MonthlyQuota != nil {
+			user.MonthlyQuota = req.MonthlyQuota
+			if *req.MonthlyQuota < 0 {
+				user.MonthlyQuota = nil
+			}
+		}
+		recordAudit(c, db, AuditUserUpdate, user.Email, "role=%s disabled=%t monthly_quota=%s", user.Role, user.Disabled, formatQuota(user.MonthlyQuota))
+	}
+
+	return c.JSON(fiber.Map{
+		"data": user,
+	})
+}
+
+// formatQuota formats a quota override for the audit log
+func formatQuota(quota *int) string {
+	if quota == nil {
+		return "default"
+	}
+	return strconv.Itoa(*quota)
+}
+
+// issueTokens starts a new session for user, signing an access token and
+// the first refresh token of a new family
+func issueTokens(c *fiber.Ctx, db *gorm.DB, user *models.User) (*TokenResponse, error) {
+	refresh, err := auth.IssueRefreshToken(db, user, "", requestClient(c))
+	if err != nil {
+		return nil, err
+	}
+	access, _, err := auth.IssueAccessToken(user)
+	if err != nil {
+		return nil, err
+	}
+	return newTokenResponse(user, access, refresh), nil
+}
+
+func newTokenResponse(user *models.User, access, refresh string) *TokenResponse {
+	return &TokenResponse{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(auth.AccessTokenTTL().Seconds()),
+		RefreshToken: refresh,
+		User:         user,
+	}
+}
+
+func requestClient(c *fiber.Ctx) auth.Client {
+	return auth.Client{UserAgent: c.Get(fiber.HeaderUserAgent), IP: c.IP()}
+}
+
+// normalizeEmail lowercases an email address, reporting whether it is valid
+func normalizeEmail(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s || len(s) > 255 {
+		return s, false
+	}
+	return s, true
+}
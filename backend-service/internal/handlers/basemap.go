@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/geo"
+	"github.com/beanspect/backend-service/internal/mbtiles"
+	"github.com/beanspect/backend-service/internal/tiles"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// BasemapHandler serves offline basemap tiles from local MBTiles files
+type BasemapHandler struct {
+	readers map[string]*mbtiles.Reader
+	names   []string
+}
+
+// NewBasemapHandler opens every MBTiles file listed in the configuration.
+// Files that cannot be opened are logged and skipped.
+func NewBasemapHandler() *BasemapHandler {
+	cfg := config.Get()
+	h := &BasemapHandler{readers: make(map[string]*mbtiles.Reader)}
+
+	for _, entry := range cfg.BasemapMBTiles {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, path, ok := strings.Cut(entry, "=")
+		if !ok {
+			path = entry
+			name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+
+		if _, exists := h.readers[name]; exists {
+			log.Warn().Str("name", name).Str("path", path).Msg("Duplicate basemap name, skipping")
+			continue
+		}
+
+		reader, err := mbtiles.Open(path)
+		if err != nil {
+			log.Error().Err(err).Str("name", name).Msg("Failed to open basemap")
+			continue
+		}
+		h.readers[name] = reader
+		h.names = append(h.names, name)
+	}
+
+	return h
+}
+
+// ListBasemaps returns the TileJSON descriptors of every configured basemap
+func (h *BasemapHandler) ListBasemaps(c *fiber.Ctx) error {
+	basemaps := make([]TileJSON, 0, len(h.names))
+	for _, name := range h.names {
+		basemaps = append(basemaps, h.tileJSON(c, name, h.readers[name]))
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  basemaps,
+		"count": len(basemaps),
+	})
+}
+
+// GetTileJSON returns the TileJSON descriptor of a single basemap
+func (h *BasemapHandler) GetTileJSON(c *fiber.Ctx) error {
+	name := c.Params("name")
+	reader, ok := h.readers[name]
+	if !ok {
+		return basemapNotFound(c)
+	}
+	return c.JSON(h.tileJSON(c, name, reader))
+}
+
+// GetTile returns a single raster or vector tile from a basemap.
+// A trailing file extension on y (e.g. "12.png") is accepted and ignored.
+func (h *BasemapHandler) GetTile(c *fiber.Ctx) error {
+	reader, ok := h.readers[c.Params("name")]
+	if !ok {
+		return basemapNotFound(c)
+	}
+
+	yParam, _, _ := strings.Cut(c.Params("y"), ".")
+	z, errZ := c.ParamsInt("z")
+	x, errX := c.ParamsInt("x")
+	y, errY := strconv.Atoi(yParam)
+	if errZ != nil || errX != nil || errY != nil || !geo.ValidTile(z, x, y) {
+		return apperr.BadRequest("INVALID_TILE", "Tile coordinates are out of range")
+	}
+
+	data, err := reader.Tile(z, x, y)
+	if errors.Is(err, mbtiles.ErrTileNotFound) {
+		return apperr.NotFound("TILE_NOT_FOUND", "Tile not found in basemap")
+	}
+	if err != nil {
+		return apperr.Internal("TILE_READ_ERROR", "Failed to read basemap tile").WithCause(err)
+	}
+
+	tile := tiles.NewCachedTile(data)
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", config.Get().TileMaxAge))
+	c.Set(fiber.HeaderETag, tile.ETag)
+	if match := c.Get(fiber.HeaderIfNoneMatch); match == "*" || strings.Contains(match, tile.ETag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	// Vector tiles in MBTiles are usually stored gzip-compressed
+	if reader.IsVector() && len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		c.Set(fiber.HeaderContentEncoding, "gzip")
+	}
+	c.Set(fiber.HeaderContentType, reader.ContentType())
+	return c.Send(data)
+}
+
+func (h *BasemapHandler) tileJSON(c *fiber.Ctx, name string, reader *mbtiles.Reader) TileJSON {
+	meta := reader.Metadata
+
+	ext := meta.Format
+	if ext == "jpeg" {
+		ext = "jpg"
+	}
+
+	bounds := meta.Bounds
+	if len(bounds) != 4 {
+		bounds = []float64{-180, -geo.MaxMercatorLat, 180, geo.MaxMercatorLat}
+	}
+
+	tj := TileJSON{
+		TileJSON:    "3.0.0",
+		Name:        name,
+		Description: meta.Description,
+		Attribution: meta.Attribution,
+		Version:     meta.Version,
+		Scheme:      "xyz",
+		Format:      meta.Format,
+		Tiles:       []string{c.BaseURL() + apiV1Prefix + "/basemap/" + name + "/{z}/{x}/{y}." + ext},
+		MinZoom:     meta.MinZoom,
+		MaxZoom:     meta.MaxZoom,
+		Bounds:      bounds,
+		Center:      meta.Center,
+	}
+	if tj.Description == "" {
+		tj.Description = meta.Name
+	}
+
+	if meta.JSON != "" {
+		var extra struct {
+			VectorLayers []VectorLayer `json:"vector_layers"`
+		}
+		if err := json.Unmarshal([]byte(meta.JSON), &extra); err == nil {
+			tj.VectorLayers = extra.VectorLayers
+		}
+	}
+
+	return tj
+}
+
+func basemapNotFound(c *fiber.Ctx) error {
+	return apperr.NotFound("BASEMAP_NOT_FOUND", "Basemap '%s' not found", c.Params("name"))
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/ical"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CalendarHandler handles the harvest calendar
+type CalendarHandler struct{}
+
+// NewCalendarHandler creates a new calendar handler
+func NewCalendarHandler() *CalendarHandler {
+	return &CalendarHandler{}
+}
+
+// HarvestEntry is a harvest window of an origin, as listed in the calendar
+type HarvestEntry struct {
+	Species    string `json:"species"`
+	CommonName string `json:"common_name"`
+	Country    string `json:"country"`
+	Region     string `json:"region"`
+	Crop       string `json:"crop"`
+	StartMonth int    `json:"start_month"`
+	EndMonth   int    `json:"end_month"`
+	Months     []int  `json:"months"`
+}
+
+// GetCalendar lists the origins in harvest during the month query
+// parameter, the current month by default. The species query parameter
+// narrows the list to one species.
+func (h *CalendarHandler) GetCalendar(c *fiber.Ctx) error {
+	month := int(time.Now().UTC().Month())
+	if raw := c.Query("month"); raw != "" {
+		m, err := strconv.Atoi(raw)
+		if err != nil || m < 1 || m > 12 {
+			return apperr.BadRequest("INVALID_MONTH", "month must be a number between %d and %d", 1, 12)
+		}
+		month = m
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	query := db.Order("species, country, region, crop")
+	if species := strings.ToLower(strings.TrimSpace(c.Query("species"))); species != "" {
+		query = query.Where("species = ?", species)
+	}
+
+	var windows []models.HarvestWindow
+	if err := query.Find(&windows).Error; err != nil {
+		return apperr.Internal("CALENDAR_FETCH_ERROR", "Failed to fetch harvest calendar").WithCause(err)
+	}
+
+	var origins []models.SpeciesOrigin
+	if err := db.Find(&origins).Error; err != nil {
+		return apperr.Internal("CALENDAR_FETCH_ERROR", "Failed to fetch harvest calendar").WithCause(err)
+	}
+	localizeOrigins(c, db, origins)
+
+	names := make(map[string]string, len(origins))
+	for _, o := range origins {
+		names[o.Species] = o.CommonName
+	}
+
+	entries := []HarvestEntry{}
+	for _, w := range windows {
+		if !w.Covers(month) {
+			continue
+		}
+		entries = append(entries, HarvestEntry{
+			Species:    w.Species,
+			CommonName: names[w.Species],
+			Country:    w.Country,
+			Region:     w.Region,
+			Crop:       w.Crop,
+			StartMonth: w.StartMonth,
+			EndMonth:   w.EndMonth,
+			Months:     w.Months(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"month": month,
+		"data":  entries,
+		"count": len(entries),
+	})
+}
+
+// ExportCalendar returns the harvest windows of a species as a yearly
+// recurring iCalendar feed that calendar apps can subscribe to
+func (h *CalendarHandler) ExportCalendar(c *fiber.Ctx) error {
+	species := strings.ToLower(c.Params("species"))
+	if species == "" {
+		return apperr.BadRequest("SPECIES_REQUIRED", "Species parameter is required")
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	var origin models.SpeciesOrigin
+	if err := db.Where("species = ?", species).First(&origin).Error; err != nil {
+		return apperr.NotFound("SPECIES_NOT_FOUND", "Species '%s' not found", species)
+	}
+	localizeOrigin(c, db, &origin)
+
+	var windows []models.HarvestWindow
+	if err := db.Where("species = ?", species).Order("country, region, crop").Find(&windows).Error; err != nil {
+		return apperr.Internal("CALENDAR_FETCH_ERROR", "Failed to fetch harvest calendar").WithCause(err)
+	}
+
+	cfg := config.Get()
+	cal := ical.Calendar{
+		ProdID: fmt.Sprintf("-//%s//%s//EN", cfg.AppName, cfg.AppVersion),
+		Name:   origin.CommonName + " harvest calendar",
+	}
+
+	// The first occurrence is a year back so a season that wrapped into
+	// this year is still shown
+	year := time.Now().UTC().Year() - 1
+	for _, w := range windows {
+		start := time.Date(year, time.Month(w.StartMonth), 1, 0, 0, 0, 0, time.UTC)
+		cal.Events = append(cal.Events, ical.Event{
+			UID:         fmt.Sprintf("harvest-%d@beanspect", w.ID),
+			Summary:     fmt.Sprintf("%s %s crop harvest (%s)", origin.CommonName, w.Crop, harvestPlace(w)),
+			Description: fmt.Sprintf("%s crop harvest of %s in %s, %s to %s.", strings.ToUpper(w.Crop[:1])+w.Crop[1:], origin.CommonName, harvestPlace(w), time.Month(w.StartMonth), time.Month(w.EndMonth)),
+			Location:    harvestPlace(w),
+			Start:       start,
+			End:         start.AddDate(0, len(w.Months()), 0),
+			RRule:       "FREQ=YEARLY",
+			Stamp:       w.UpdatedAt,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Write(&buf); err != nil {
+		return apperr.Internal("CALENDAR_EXPORT_ERROR", "Failed to export harvest calendar").WithCause(err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Attachment(fmt.Sprintf("beanspect-harvest-%s.ics", species))
+	return c.Send(buf.Bytes())
+}
+
+// harvestPlace is the region and country of a window
+func harvestPlace(w models.HarvestWindow) string {
+	if w.Region == "" {
+		return w.Country
+	}
+	return w.Region + ", " + w.Country
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/geo"
+	"github.com/beanspect/backend-service/internal/geocode"
+	"github.com/beanspect/backend-service/internal/importer"
+	"github.com/beanspect/backend-service/internal/middleware"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/beanspect/backend-service/internal/tiles"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Choropleth settings
+const (
+	// countriesCacheLayer prefixes the cache keys of rendered choropleths
+	countriesCacheLayer  = "countries"
+	countriesCacheSize   = 64
+	countriesMaxZoom     = 10
+	countriesDefaultZoom = 2
+)
+
+// countryStats is the cultivation data of one country
+type countryStats struct {
+	species map[string]bool
+	volumes map[string]float64
+	years   map[string]int
+}
+
+// GetCountriesGeoJSON returns country polygons annotated with the catalog
+// species grown there and their latest production volume in tonnes.
+// zoom simplifies the polygons for display at that zoom level, species
+// limits the annotation to a comma-separated list, and all=true includes
+// countries without any species.
+func (h *OriginHandler) GetCountriesGeoJSON(c *fiber.Ctx) error {
+	zoom := c.QueryInt("zoom", countriesDefaultZoom)
+	if zoom < 0 || zoom > geo.MaxZoom {
+		return apperr.BadRequest("INVALID_ZOOM", "zoom must be between 0 and %d", geo.MaxZoom)
+	}
+	// The embedded boundaries gain no detail beyond this zoom
+	zoom = int(math.Min(float64(zoom), countriesMaxZoom))
+	filter := splitList(c.Query("species"))
+	all := c.QueryBool("all")
+
+	g := geocode.Get()
+	if g == nil {
+		return apperr.Unavailable("BOUNDARIES_NOT_LOADED", "Country boundaries are not available")
+	}
+
+	key := fmt.Sprintf("%s/%d/%s/%t", countriesCacheLayer, zoom, strings.Join(filter, ","), all)
+	doc, ok := h.countries.Get(key)
+	if !ok {
+		db := database.Get()
+		if db == nil {
+			return errDBNotConnected
+		}
+
+		stats, err := loadCountryStats(db, g, filter)
+		if err != nil {
+			return apperr.Internal("COUNTRIES_FETCH_ERROR", "Failed to build country cultivation layer").WithCause(err)
+		}
+
+		data, err := json.Marshal(countryFeatureCollection(g, stats, zoom, all))
+		if err != nil {
+			return apperr.Internal("COUNTRIES_FETCH_ERROR", "Failed to build country cultivation layer").WithCause(err)
+		}
+		doc = tiles.NewCachedTile(data)
+		h.countries.Set(key, doc)
+	}
+
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", config.Get().TileMaxAge))
+	c.Set(fiber.HeaderETag, doc.ETag)
+	if match := c.Get(fiber.HeaderIfNoneMatch); match == "*" || strings.Contains(match, doc.ETag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/geo+json")
+	return c.Send(doc.Data)
+}
+
+// ImportProduction loads country production volumes from an uploaded CSV
+// with country, species, volume_tonnes and optional year and source
+// columns. The mode query parameter works as for origin imports.
+func (h *OriginHandler) ImportProduction(c *fiber.Ctx) error {
+	mode, err := parseImportMode(c)
+	if err != nil {
+		return err
+	}
+
+	var content []byte
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			return apperr.BadRequest("FILE_OPEN_ERROR", "Failed to open uploaded file").WithCause(err)
+		}
+		defer f.Close()
+
+		content, err = io.ReadAll(f)
+		if err != nil {
+			return apperr.BadRequest("FILE_READ_ERROR", "Failed to read uploaded file").WithCause(err)
+		}
+	} else {
+		content = c.Body()
+	}
+
+	if len(bytes.TrimSpace(content)) == 0 {
+		return apperr.BadRequest("PRODUCTION_FILE_REQUIRED", "A CSV file is required")
+	}
+
+	rows, err := importer.ParseProductionCSV(bytes.NewReader(content))
+	if err != nil {
+		return importParseError(importer.FormatCSV, err)
+	}
+	if len(rows) == 0 {
+		return apperr.BadRequest("IMPORT_EMPTY", "Import file contains no rows")
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	result, err := importer.RunProduction(db, rows, mode)
+	if errors.Is(err, importer.ErrInvalidRows) {
+		return apperr.Unprocessable("IMPORT_INVALID_ROWS", "%d row(s) failed validation, nothing was imported", len(result.Errors)).WithDetails(result)
+	}
+	if err != nil {
+		return apperr.Internal("PRODUCTION_IMPORT_ERROR", "Failed to import production volumes").WithCause(err)
+	}
+
+	middleware.Log(c).Info().
+		Str("mode", string(mode)).
+		Int("inserted", result.Inserted).
+		Int("updated", result.Updated).
+		Bool("committed", result.Committed).
+		Msg("Imported production volumes")
+	if result.Committed {
+		recordAudit(c, db, AuditProductionImport, "", "inserted=%d updated=%d", result.Inserted, result.Updated)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": result,
+	})
+}
+
+// loadCountryStats gathers, per ISO alpha-3 code, the species grown in a
+// country from the catalog origins and the production table. Only the
+// latest year of production is kept for each species.
+func loadCountryStats(db *gorm.DB, g *geocode.Geocoder, filter []string) (map[string]*countryStats, error) {
+	wanted := func(species string) bool {
+		if len(filter) == 0 {
+			return true
+		}
+		for _, f := range filter {
+			if f == species {
+				return true
+			}
+		}
+		return false
+	}
+
+	stats := make(map[string]*countryStats)
+	get := func(code string) *countryStats {
+		s, ok := stats[code]
+		if !ok {
+			s = &countryStats{species: map[string]bool{}, volumes: map[string]float64{}, years: map[string]int{}}
+			stats[code] = s
+		}
+		return s
+	}
+
+	var origins []models.SpeciesOrigin
+	if err := db.Select("species", "country").Find(&origins).Error; err != nil {
+		return nil, err
+	}
+	for _, o := range origins {
+		if !wanted(o.Species) {
+			continue
+		}
+		if country := g.LookupCountry(o.Country); country != nil && country.ISOA3 != "" {
+			get(country.ISOA3).species[o.Species] = true
+		}
+	}
+
+	var production []models.CountryProduction
+	if err := db.Find(&production).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range production {
+		if !wanted(p.Species) {
+			continue
+		}
+		s := get(p.CountryCode)
+		s.species[p.Species] = true
+		if year, seen := s.years[p.Species]; !seen || p.Year > year {
+			s.years[p.Species] = p.Year
+			s.volumes[p.Species] = p.VolumeTonnes
+		}
+	}
+	return stats, nil
+}
+
+// countryFeatureCollection merges the boundary parts of each country and
+// annotates them with their cultivation data
+func countryFeatureCollection(g *geocode.Geocoder, stats map[string]*countryStats, zoom int, all bool) fiber.Map {
+	type country struct {
+		shape    geocode.CountryShape
+		polygons []geo.Polygon
+	}
+	byCode := make(map[string]*country)
+	var codes []string
+	for _, shape := range g.Countries() {
+		code := shape.ISOA3
+		if code == "" {
+			continue
+		}
+		if _, ok := stats[code]; !ok && !all {
+			continue
+		}
+		if c, ok := byCode[code]; ok {
+			c.polygons = append(c.polygons, shape.Polygons...)
+			continue
+		}
+		byCode[code] = &country{shape: shape, polygons: append([]geo.Polygon(nil), shape.Polygons...)}
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	tolerance := geo.DegreesPerPixel(zoom)
+	features := make([]fiber.Map, 0, len(codes))
+	for _, code := range codes {
+		c := byCode[code]
+
+		species := []string{}
+		volumes := map[string]float64{}
+		var total *float64
+		var year int
+		if s, ok := stats[code]; ok {
+			for name := range s.species {
+				species = append(species, name)
+			}
+			sort.Strings(species)
+			for name, v := range s.volumes {
+				volumes[name] = v
+				sum := v
+				if total != nil {
+					sum += *total
+				}
+				total = &sum
+				if s.years[name] > year {
+					year = s.years[name]
+				}
+			}
+		}
+
+		properties := fiber.Map{
+			"name":          c.shape.Name,
+			"iso_a2":        c.shape.ISOA2,
+			"iso_a3":        code,
+			"species":       species,
+			"species_count": len(species),
+			"volume_tonnes": total,
+			"volumes":       volumes,
+		}
+		if year > 0 {
+			properties["year"] = year
+		}
+
+		features = append(features, fiber.Map{
+			"type":       "Feature",
+			"id":         code,
+			"geometry":   multiPolygonGeometry(geo.SimplifyPolygons(visiblePolygons(c.polygons, tolerance), tolerance)),
+			"properties": properties,
+		})
+	}
+
+	return fiber.Map{
+		"type":     "FeatureCollection",
+		"features": features,
+	}
+}
+
+// visiblePolygons drops islands smaller than a pixel at the target zoom,
+// keeping at least the largest polygon of a country
+func visiblePolygons(polys []geo.Polygon, tolerance float64) []geo.Polygon {
+	out := make([]geo.Polygon, 0, len(polys))
+	largest, largestSize := 0, -1.0
+	for i, p := range polys {
+		b := geo.PolygonBounds(p)
+		size := math.Max(b.MaxLng-b.MinLng, b.MaxLat-b.MinLat)
+		if size > largestSize {
+			largest, largestSize = i, size
+		}
+		if size >= tolerance {
+			out = append(out, p)
+		}
+	}
+	if len(out) == 0 && len(polys) > 0 {
+		out = append(out, polys[largest])
+	}
+	return out
+}
+
+// multiPolygonGeometry converts polygons to a GeoJSON MultiPolygon
+func multiPolygonGeometry(polys []geo.Polygon) fiber.Map {
+	coords := make([][][][2]float64, len(polys))
+	for i, poly := range polys {
+		coords[i] = make([][][2]float64, len(poly))
+		for j, ring := range poly {
+			coords[i][j] = make([][2]float64, len(ring))
+			for k, p := range ring {
+				coords[i][j][k] = [2]float64{p.Lng, p.Lat}
+			}
+		}
+	}
+	return fiber.Map{
+		"type":        "MultiPolygon",
+		"coordinates": coords,
+	}
+}
@@ -0,0 +1,778 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/export"
+	"github.com/beanspect/backend-service/internal/geocode"
+	"github.com/beanspect/backend-service/internal/i18n"
+	"github.com/beanspect/backend-service/internal/importer"
+	"github.com/beanspect/backend-service/internal/middleware"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/beanspect/backend-service/internal/openapi"
+	"github.com/beanspect/backend-service/internal/recommend"
+	"github.com/beanspect/backend-service/internal/sensory"
+	"github.com/beanspect/backend-service/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// GeoJSONFeatureCollection documents the GeoJSON responses, which are
+// assembled as maps
+type GeoJSONFeatureCollection struct {
+	Type       string                 `json:"type"`
+	Features   []GeoJSONFeature       `json:"features"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// GeoJSONFeature is one feature of a GeoJSON FeatureCollection
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   GeoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONGeometry is a Point, Polygon or MultiPolygon geometry
+type GeoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// errorStatus is the HTTP status of every error code the API returns
+var errorStatus = map[string]int{
+	"API_KEY_INVALID":          http.StatusUnauthorized,
+	"AUDIT_FETCH_ERROR":        http.StatusInternalServerError,
+	"AUTH_ERROR":               http.StatusInternalServerError,
+	"AUTH_REQUIRED":            http.StatusUnauthorized,
+	"BASEMAP_NOT_FOUND":        http.StatusNotFound,
+	"BOUNDARIES_NOT_LOADED":    http.StatusServiceUnavailable,
+	"CALENDAR_EXPORT_ERROR":    http.StatusInternalServerError,
+	"CALENDAR_FETCH_ERROR":     http.StatusInternalServerError,
+	"COUNTRIES_FETCH_ERROR":    http.StatusInternalServerError,
+	"DB_NOT_CONNECTED":         http.StatusServiceUnavailable,
+	"DOCS_ERROR":               http.StatusInternalServerError,
+	"ELEVATION_UNAVAILABLE":    http.StatusInternalServerError,
+	"EMAIL_TAKEN":              http.StatusConflict,
+	"EXPORT_ERROR":             http.StatusInternalServerError,
+	"FETCH_ERROR":              http.StatusInternalServerError,
+	"FILE_OPEN_ERROR":          http.StatusBadRequest,
+	"FILE_READ_ERROR":          http.StatusBadRequest,
+	"FILE_REQUIRED":            http.StatusBadRequest,
+	"FLAVOR_FETCH_ERROR":       http.StatusInternalServerError,
+	"FLAVOR_NOT_FOUND":         http.StatusBadRequest,
+	"GEOCODER_NOT_LOADED":      http.StatusServiceUnavailable,
+	"HEATMAP_ERROR":            http.StatusInternalServerError,
+	"IMPORT_EMPTY":             http.StatusBadRequest,
+	"IMPORT_ERROR":             http.StatusInternalServerError,
+	"IMPORT_FILE_REQUIRED":     http.StatusBadRequest,
+	"IMPORT_INVALID_ROWS":      http.StatusUnprocessableEntity,
+	"IMPORT_PARSE_ERROR":       http.StatusBadRequest,
+	"INFERENCE_ERROR":          http.StatusServiceUnavailable,
+	"INFERENCE_REJECTED":       http.StatusBadRequest,
+	"INFERENCE_UNAVAILABLE":    http.StatusServiceUnavailable,
+	"INSUFFICIENT_SCOPE":       http.StatusForbidden,
+	"INTERNAL_ERROR":           http.StatusInternalServerError,
+	"INVALID_BBOX":             http.StatusBadRequest,
+	"INVALID_COMPARISON":       http.StatusBadRequest,
+	"INVALID_COORDINATES":      http.StatusBadRequest,
+	"INVALID_CREDENTIALS":      http.StatusUnauthorized,
+	"INVALID_EMAIL":            http.StatusBadRequest,
+	"INVALID_FIELD":            http.StatusBadRequest,
+	"INVALID_ID":               http.StatusBadRequest,
+	"INVALID_IMPORT_FORMAT":    http.StatusBadRequest,
+	"INVALID_IMPORT_MODE":      http.StatusBadRequest,
+	"INVALID_LOCATION":         http.StatusBadRequest,
+	"INVALID_MONTH":            http.StatusBadRequest,
+	"INVALID_PASSWORD":         http.StatusBadRequest,
+	"INVALID_RANGE":            http.StatusBadRequest,
+	"INVALID_REQUEST":          http.StatusBadRequest,
+	"INVALID_ROLE":             http.StatusBadRequest,
+	"INVALID_SITE":             http.StatusBadRequest,
+	"INVALID_THRESHOLD":        http.StatusBadRequest,
+	"INVALID_TILE":             http.StatusBadRequest,
+	"INVALID_TOP_K":            http.StatusBadRequest,
+	"INVALID_USAGE_MONTH":      http.StatusBadRequest,
+	"INVALID_USER_ID":          http.StatusBadRequest,
+	"INVALID_ZOOM":             http.StatusBadRequest,
+	"LAYER_NOT_FOUND":          http.StatusNotFound,
+	"LOCATION_NOT_FOUND":       http.StatusNotFound,
+	"LOGIN_ERROR":              http.StatusInternalServerError,
+	"LOGOUT_ERROR":             http.StatusInternalServerError,
+	"PRODUCTION_FILE_REQUIRED": http.StatusBadRequest,
+	"PRODUCTION_IMPORT_ERROR":  http.StatusInternalServerError,
+	"QUOTA_EXCEEDED":           http.StatusTooManyRequests,
+	"RATE_LIMITED":             http.StatusTooManyRequests,
+	"REFRESH_TOKEN_INVALID":    http.StatusUnauthorized,
+	"REGISTRATION_ERROR":       http.StatusInternalServerError,
+	"ROUTE_NOT_FOUND":          http.StatusNotFound,
+	"SELF_LOCKOUT":             http.StatusBadRequest,
+	"SPECIES_NOT_FOUND":        http.StatusNotFound,
+	"SPECIES_REQUIRED":         http.StatusBadRequest,
+	"SUITABILITY_ERROR":        http.StatusInternalServerError,
+	"TILE_NOT_FOUND":           http.StatusNotFound,
+	"TILE_READ_ERROR":          http.StatusInternalServerError,
+	"TILE_RENDER_ERROR":        http.StatusInternalServerError,
+	"TOKEN_INVALID":            http.StatusUnauthorized,
+	"TOKEN_ISSUE_ERROR":        http.StatusInternalServerError,
+	"TRANSLATION_DELETE_ERROR": http.StatusInternalServerError,
+	"TRANSLATION_FETCH_ERROR":  http.StatusInternalServerError,
+	"TRANSLATION_NOT_FOUND":    http.StatusNotFound,
+	"TRANSLATION_SAVE_ERROR":   http.StatusInternalServerError,
+	"UNSUPPORTED_FORMAT":       http.StatusBadRequest,
+	"UNSUPPORTED_LOCALE":       http.StatusBadRequest,
+	"USAGE_FETCH_ERROR":        http.StatusInternalServerError,
+	"USER_DISABLED":            http.StatusForbidden,
+	"USER_FETCH_ERROR":         http.StatusInternalServerError,
+	"USER_NOT_FOUND":           http.StatusNotFound,
+	"USER_REQUIRED":            http.StatusForbidden,
+	"USER_UPDATE_ERROR":        http.StatusInternalServerError,
+	"VALUE_REQUIRED":           http.StatusBadRequest,
+}
+
+// API prefixes; the legacy routes alias v1 and are left out of the document
+const (
+	apiV1Prefix     = "/api/v1"
+	legacyAPIPrefix = "/api"
+)
+
+// Security schemes of the document
+const (
+	schemeBearer = "bearerAuth"
+	schemeAPIKey = "apiKey"
+)
+
+// Response headers of the document
+var (
+	rateLimitHeaders = []string{"RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"}
+	quotaHeaders     = []string{middleware.HeaderQuotaLimit, middleware.HeaderQuotaRemaining, middleware.HeaderQuotaReset}
+)
+
+// authErrors are returned by any route that resolves credentials
+var authErrors = []string{"AUTH_REQUIRED", "API_KEY_INVALID", "TOKEN_INVALID", "USER_DISABLED", "AUTH_ERROR", "DB_NOT_CONNECTED"}
+
+// scoped marks a route as requiring scope from a user role or API key
+func scoped(scope string, r openapi.Route) openapi.Route {
+	r.Security = []string{schemeBearer, schemeAPIKey}
+	r.Description = strings.TrimSpace(r.Description + fmt.Sprintf(" Requires the `%s` scope.", scope))
+	r.Errors = append(append(r.Errors, authErrors...), "INSUFFICIENT_SCOPE")
+	return r
+}
+
+// signedIn marks a route as requiring a signed-in user
+func signedIn(r openapi.Route) openapi.Route {
+	r.Security = []string{schemeBearer}
+	r.Errors = append(append(r.Errors, authErrors...), "USER_REQUIRED")
+	return r
+}
+
+// metered marks a route as counting against the monthly quota
+func metered(r openapi.Route) openapi.Route {
+	r.Description = strings.TrimSpace(r.Description + " Counts against the caller's monthly quota.")
+	r.Headers = append(r.Headers, quotaHeaders...)
+	r.Errors = append(r.Errors, "QUOTA_EXCEEDED")
+	return r
+}
+
+// Parameters shared by several routes
+var (
+	rangeParams = []openapi.Param{
+		{Name: "caffeine_min", Type: "number", Description: "Keep species whose caffeine range reaches at least this percentage"},
+		{Name: "caffeine_max", Type: "number", Description: "Keep species whose caffeine range starts at most at this percentage"},
+		{Name: "altitude_min", Type: "number", Description: "Keep species grown up to at least this altitude in metres"},
+		{Name: "altitude_max", Type: "number", Description: "Keep species grown from at most this altitude in metres"},
+		{Name: "sort", Enum: []string{"species", "caffeine", "-caffeine", "altitude", "-altitude"}},
+	}
+	importParams = []openapi.Param{
+		{Name: "mode", Enum: importer.Modes(), Description: "Validate only, insert new rows, or insert and update; dry-run by default"},
+	}
+	imageForm = []openapi.Param{
+		{Name: "file", Type: "file", Required: true, Description: "Image of the coffee beans"},
+	}
+	importErrors = []string{"INVALID_IMPORT_MODE", "FILE_OPEN_ERROR", "FILE_READ_ERROR", "IMPORT_PARSE_ERROR", "IMPORT_EMPTY", "IMPORT_INVALID_ROWS", "DB_NOT_CONNECTED"}
+)
+
+// routeDocs annotates every registered route, keyed by method and path
+var routeDocs = map[string]openapi.Route{
+	"GET /": {
+		Tag:      "Service",
+		Summary:  "Describe the service",
+		Response: map[string]string{},
+		Raw:      true,
+	},
+	"GET /health": {
+		Tag:      "Service",
+		Summary:  "Report service health",
+		Response: HealthResponse{},
+		Raw:      true,
+	},
+
+	// Docs
+	"GET /api/docs": {
+		Tag:         "Service",
+		Summary:     "Browse the API reference",
+		ContentType: fiber.MIMETextHTMLCharsetUTF8,
+	},
+	"GET /api/docs/openapi.json": {
+		Tag:         "Service",
+		Summary:     "Get the OpenAPI document as JSON",
+		ContentType: fiber.MIMEApplicationJSON,
+		Errors:      []string{"DOCS_ERROR"},
+	},
+	"GET /api/docs/openapi.yaml": {
+		Tag:         "Service",
+		Summary:     "Get the OpenAPI document as YAML",
+		ContentType: "application/yaml",
+		Errors:      []string{"DOCS_ERROR"},
+	},
+
+	// Auth
+	"POST /api/v1/auth/register": {
+		Tag:         "Auth",
+		Summary:     "Register an account",
+		Description: "Creates a grader account and signs it in.",
+		Body:        RegisterRequest{},
+		Response:    TokenResponse{},
+		Status:      http.StatusCreated,
+		Errors:      []string{"INVALID_REQUEST", "INVALID_EMAIL", "INVALID_PASSWORD", "EMAIL_TAKEN", "REGISTRATION_ERROR", "TOKEN_ISSUE_ERROR", "DB_NOT_CONNECTED"},
+	},
+	"POST /api/v1/auth/login": {
+		Tag:      "Auth",
+		Summary:  "Sign in",
+		Body:     LoginRequest{},
+		Response: TokenResponse{},
+		Errors:   []string{"INVALID_REQUEST", "INVALID_CREDENTIALS", "USER_DISABLED", "LOGIN_ERROR", "TOKEN_ISSUE_ERROR", "DB_NOT_CONNECTED"},
+	},
+	"POST /api/v1/auth/refresh": {
+		Tag:         "Auth",
+		Summary:     "Rotate a refresh token",
+		Description: "Reusing a rotated refresh token revokes every token of its family.",
+		Body:        RefreshRequest{},
+		Response:    TokenResponse{},
+		Errors:      []string{"INVALID_REQUEST", "REFRESH_TOKEN_INVALID", "USER_DISABLED", "TOKEN_ISSUE_ERROR", "DB_NOT_CONNECTED"},
+	},
+	"POST /api/v1/auth/logout": {
+		Tag:     "Auth",
+		Summary: "Revoke a refresh token",
+		Body:    RefreshRequest{},
+		Status:  http.StatusNoContent,
+		Errors:  []string{"INVALID_REQUEST", "LOGOUT_ERROR", "DB_NOT_CONNECTED"},
+	},
+	"GET /api/v1/auth/me": signedIn(openapi.Route{
+		Tag:      "Auth",
+		Summary:  "Get the signed-in user",
+		Response: models.User{},
+	}),
+
+	// Inference
+	"POST /api/v1/predict": scoped(models.ScopeAnalyze, metered(openapi.Route{
+		Tag:      "Inference",
+		Summary:  "Classify a bean image",
+		Form:     imageForm,
+		Response: services.PredictionResponse{},
+		Raw:      true,
+		Errors:   []string{"FILE_REQUIRED", "FILE_OPEN_ERROR", "FILE_READ_ERROR", "INFERENCE_REJECTED", "INFERENCE_ERROR", "INFERENCE_UNAVAILABLE"},
+	})),
+	"POST /api/v1/analyze": scoped(models.ScopeAnalyze, metered(openapi.Route{
+		Tag:         "Inference",
+		Summary:     "Classify a bean image with its origin",
+		Description: "Combines the prediction with the origin and sensory profile of the species and stores the analysis.",
+		Query: []openapi.Param{
+			{Name: "top_k", Type: "integer", Description: "Attach origins for the k most probable classes"},
+			{Name: "threshold", Type: "number", Description: "Attach origins for classes with at least this confidence"},
+			{Name: "similar", Type: "boolean", Description: "Recommend species similar to the prediction"},
+			{Name: "use_exif", Type: "boolean", Description: "Geotag the analysis from the image's EXIF GPS position"},
+		},
+		Form: append(append([]openapi.Param{}, imageForm...),
+			openapi.Param{Name: "latitude", Type: "number", Description: "Latitude of the scan"},
+			openapi.Param{Name: "longitude", Type: "number", Description: "Longitude of the scan"},
+			openapi.Param{Name: "accuracy", Type: "number", Description: "Accuracy of the scan position in metres"},
+			openapi.Param{Name: "use_exif", Type: "boolean"},
+		),
+		Response: AnalyzeResponse{},
+		Raw:      true,
+		Errors:   []string{"INVALID_TOP_K", "INVALID_THRESHOLD", "FILE_REQUIRED", "FILE_OPEN_ERROR", "FILE_READ_ERROR", "INVALID_LOCATION", "INFERENCE_REJECTED", "INFERENCE_ERROR", "INFERENCE_UNAVAILABLE"},
+	})),
+
+	// Origins
+	"GET /api/v1/origins": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:      "Origins",
+		Summary:  "List species origins",
+		Query:    rangeParams,
+		Response: models.SpeciesOrigin{},
+		List:     true,
+		Errors:   []string{"INVALID_RANGE", "FETCH_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"GET /api/v1/origins/geojson": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:      "Origins",
+		Summary:  "Get species origins as GeoJSON points",
+		Response: GeoJSONFeatureCollection{},
+		Raw:      true,
+		Errors:   []string{"FETCH_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"GET /api/v1/origins/export": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:         "Origins",
+		Summary:     "Download species origins",
+		Query:       []openapi.Param{{Name: "format", Enum: export.Formats(), Description: "csv by default"}},
+		ContentType: "application/octet-stream",
+		Errors:      []string{"UNSUPPORTED_FORMAT", "FETCH_ERROR", "EXPORT_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"POST /api/v1/origins/import": scoped(models.ScopeOriginsWrite, openapi.Route{
+		Tag:         "Origins",
+		Summary:     "Import species origins",
+		Description: "Loads a GeoJSON FeatureCollection or CSV file in one transaction, sent as a multipart upload or as the raw body.",
+		Query: append(append([]openapi.Param{}, importParams...),
+			openapi.Param{Name: "format", Enum: importer.Formats(), Description: "Detected from the file when omitted"}),
+		Form:     []openapi.Param{{Name: "file", Type: "file", Required: true}},
+		RawBody:  []string{"application/geo+json", "text/csv"},
+		Response: importer.Result{},
+		Errors:   append(append([]string{}, importErrors...), "INVALID_IMPORT_FORMAT", "IMPORT_FILE_REQUIRED", "IMPORT_ERROR"),
+	}),
+	"GET /api/v1/origins/countries.geojson": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:         "Origins",
+		Summary:     "Get producing countries as GeoJSON",
+		Description: "Country polygons annotated with the species grown there and their latest production volume.",
+		Query: []openapi.Param{
+			{Name: "zoom", Type: "integer", Description: "Simplify polygons for display at this zoom"},
+			{Name: "species", Description: "Comma-separated species to annotate"},
+			{Name: "all", Type: "boolean", Description: "Include countries without species"},
+		},
+		ContentType: "application/geo+json",
+		Errors:      []string{"INVALID_ZOOM", "BOUNDARIES_NOT_LOADED", "COUNTRIES_FETCH_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"POST /api/v1/origins/production/import": scoped(models.ScopeOriginsWrite, openapi.Route{
+		Tag:      "Origins",
+		Summary:  "Import country production volumes",
+		Query:    importParams,
+		Form:     []openapi.Param{{Name: "file", Type: "file", Required: true}},
+		RawBody:  []string{"text/csv"},
+		Response: importer.Result{},
+		Errors:   append(append([]string{}, importErrors...), "PRODUCTION_FILE_REQUIRED", "PRODUCTION_IMPORT_ERROR"),
+	}),
+	"GET /api/v1/origin/:species": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:      "Origins",
+		Summary:  "Get the origin of a species",
+		Response: models.SpeciesOrigin{},
+		Errors:   []string{"SPECIES_REQUIRED", "SPECIES_NOT_FOUND", "DB_NOT_CONNECTED"},
+	}),
+
+	// Species
+	"GET /api/v1/species": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:      "Species",
+		Summary:  "List species with sensory profiles",
+		Query:    append([]openapi.Param{{Name: "flavor", Description: "Flavor wheel tag, matching the tags beneath it too"}}, rangeParams...),
+		Response: SpeciesData{},
+		List:     true,
+		Errors:   []string{"INVALID_RANGE", "FLAVOR_NOT_FOUND", "FETCH_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"GET /api/v1/species/radar": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:      "Species",
+		Summary:  "Get sensory scores as radar chart datasets",
+		Query:    []openapi.Param{{Name: "species", Description: "Comma-separated species, every species by default"}},
+		Response: RadarResponse{},
+		Raw:      true,
+		Errors:   []string{"FETCH_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"GET /api/v1/species/compare": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:      "Species",
+		Summary:  "Compare species side by side",
+		Query:    []openapi.Param{{Name: "species", Required: true, Description: "Two to four comma-separated species"}},
+		Response: ComparisonData{},
+		Errors:   []string{"INVALID_COMPARISON", "SPECIES_NOT_FOUND", "FETCH_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"GET /api/v1/species/:species/similar": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:      "Species",
+		Summary:  "Rank species similar to a species",
+		Query:    []openapi.Param{{Name: "limit", Type: "integer", Description: "Keep at most this many matches"}},
+		Response: recommend.Match{},
+		List:     true,
+		Fields:   map[string]interface{}{"species": ""},
+		Errors:   []string{"SPECIES_NOT_FOUND", "FETCH_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"GET /api/v1/flavors": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:      "Species",
+		Summary:  "Get the flavor wheel",
+		Response: []*sensory.TagNode{},
+		Errors:   []string{"FLAVOR_FETCH_ERROR", "DB_NOT_CONNECTED"},
+	}),
+
+	// Geo
+	"GET /api/v1/geo/reverse": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:     "Geo",
+		Summary: "Find the country and region of a point",
+		Query: []openapi.Param{
+			{Name: "lat", Type: "number", Required: true},
+			{Name: "lng", Type: "number", Required: true},
+		},
+		Response: geocode.Place{},
+		Errors:   []string{"INVALID_COORDINATES", "GEOCODER_NOT_LOADED", "LOCATION_NOT_FOUND"},
+	}),
+	"POST /api/v1/suitability": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:         "Geo",
+		Summary:     "Estimate how well species suit a growing site",
+		Description: "Elevation is read from the elevation model when it is not supplied.",
+		Body:        SuitabilityRequest{},
+		Response:    SuitabilityData{},
+		Fields:      map[string]interface{}{"count": 0},
+		Errors:      []string{"INVALID_REQUEST", "INVALID_COORDINATES", "INVALID_SITE", "ELEVATION_UNAVAILABLE", "SUITABILITY_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"GET /api/v1/calendar": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:     "Geo",
+		Summary: "List origins in harvest during a month",
+		Query: []openapi.Param{
+			{Name: "month", Type: "integer", Description: "1 to 12, the current month by default"},
+			{Name: "species"},
+		},
+		Response: HarvestEntry{},
+		List:     true,
+		Fields:   map[string]interface{}{"month": 0},
+		Errors:   []string{"INVALID_MONTH", "CALENDAR_FETCH_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"GET /api/v1/calendar/:species.ics": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:         "Geo",
+		Summary:     "Subscribe to the harvest calendar of a species",
+		Description: "Calendar apps that cannot send headers may pass the API key as the api_key query parameter.",
+		ContentType: "text/calendar",
+		Errors:      []string{"SPECIES_REQUIRED", "SPECIES_NOT_FOUND", "CALENDAR_FETCH_ERROR", "CALENDAR_EXPORT_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"GET /api/v1/analyses/heatmap": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:     "Geo",
+		Summary: "Aggregate scan locations into geohash cells",
+		Query: []openapi.Param{
+			{Name: "bbox", Description: "minLng,minLat,maxLng,maxLat"},
+			{Name: "zoom", Type: "integer", Description: "Selects the cell size"},
+			{Name: "species", Description: "Comma-separated species"},
+		},
+		Response: GeoJSONFeatureCollection{},
+		Raw:      true,
+		Errors:   []string{"INVALID_BBOX", "INVALID_ZOOM", "HEATMAP_ERROR", "DB_NOT_CONNECTED"},
+	}),
+
+	// Usage
+	"GET /api/v1/usage": scoped(models.ScopeAnalyze, openapi.Route{
+		Tag:      "Usage",
+		Summary:  "Get the caller's metered usage",
+		Query:    []openapi.Param{{Name: "month", Description: "YYYY-MM, the current month by default"}},
+		Response: UsageData{},
+		Errors:   []string{"INVALID_USAGE_MONTH", "USAGE_FETCH_ERROR", "DB_NOT_CONNECTED"},
+	}),
+
+	// Tiles
+	"GET /api/v1/tiles": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:      "Tiles",
+		Summary:  "List vector tile layers",
+		Response: TileJSON{},
+		List:     true,
+	}),
+	"GET /api/v1/tiles/:layer.json": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:      "Tiles",
+		Summary:  "Get the TileJSON of a layer",
+		Response: TileJSON{},
+		Raw:      true,
+		Errors:   []string{"LAYER_NOT_FOUND"},
+	}),
+	"GET /api/v1/tiles/:layer/:z/:x/:y.mvt": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:         "Tiles",
+		Summary:     "Get a vector tile",
+		ContentType: MVTContentType,
+		Errors:      []string{"LAYER_NOT_FOUND", "INVALID_TILE", "TILE_RENDER_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"GET /api/v1/basemap": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:      "Tiles",
+		Summary:  "List basemaps",
+		Response: TileJSON{},
+		List:     true,
+	}),
+	"GET /api/v1/basemap/:name.json": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:      "Tiles",
+		Summary:  "Get the TileJSON of a basemap",
+		Response: TileJSON{},
+		Raw:      true,
+		Errors:   []string{"BASEMAP_NOT_FOUND"},
+	}),
+	"GET /api/v1/basemap/:name/:z/:x/:y": scoped(models.ScopeOriginsRead, openapi.Route{
+		Tag:         "Tiles",
+		Summary:     "Get a basemap tile",
+		ContentType: "application/octet-stream",
+		Errors:      []string{"BASEMAP_NOT_FOUND", "INVALID_TILE", "TILE_NOT_FOUND", "TILE_READ_ERROR"},
+	}),
+
+	// Admin
+	"GET /api/v1/admin/translations": scoped(models.ScopeAdmin, openapi.Route{
+		Tag:     "Admin",
+		Summary: "List species translations",
+		Query: []openapi.Param{
+			{Name: "species"},
+			{Name: "locale"},
+		},
+		Response: models.SpeciesTranslation{},
+		List:     true,
+		Errors:   []string{"TRANSLATION_FETCH_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"PUT /api/v1/admin/translations": scoped(models.ScopeAdmin, openapi.Route{
+		Tag:      "Admin",
+		Summary:  "Create or replace a species translation",
+		Body:     TranslationRequest{},
+		Response: models.SpeciesTranslation{},
+		Errors:   []string{"INVALID_REQUEST", "SPECIES_REQUIRED", "INVALID_FIELD", "UNSUPPORTED_LOCALE", "VALUE_REQUIRED", "SPECIES_NOT_FOUND", "TRANSLATION_SAVE_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"DELETE /api/v1/admin/translations/:id": scoped(models.ScopeAdmin, openapi.Route{
+		Tag:     "Admin",
+		Summary: "Delete a species translation",
+		Status:  http.StatusNoContent,
+		Errors:  []string{"INVALID_ID", "TRANSLATION_NOT_FOUND", "TRANSLATION_DELETE_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"GET /api/v1/admin/users": scoped(models.ScopeAdmin, openapi.Route{
+		Tag:      "Admin",
+		Summary:  "List users",
+		Response: models.User{},
+		List:     true,
+		Errors:   []string{"USER_FETCH_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"PATCH /api/v1/admin/users/:id": scoped(models.ScopeAdmin, openapi.Route{
+		Tag:         "Admin",
+		Summary:     "Change a user's role, status or quota",
+		Description: "A negative monthly_quota restores the default quota.",
+		Body:        UserUpdateRequest{},
+		Response:    models.User{},
+		Errors:      []string{"INVALID_USER_ID", "INVALID_REQUEST", "INVALID_ROLE", "SELF_LOCKOUT", "USER_NOT_FOUND", "USER_UPDATE_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"GET /api/v1/admin/usage": scoped(models.ScopeAdmin, openapi.Route{
+		Tag:         "Admin",
+		Summary:     "Report metered usage per client",
+		Description: "With format=csv the report is returned as a CSV file.",
+		Query: []openapi.Param{
+			{Name: "month", Description: "YYYY-MM, the current month by default"},
+			{Name: "format", Enum: []string{"json", "csv"}},
+		},
+		Response: ReportRow{},
+		List:     true,
+		Errors:   []string{"INVALID_USAGE_MONTH", "UNSUPPORTED_FORMAT", "USAGE_FETCH_ERROR", "DB_NOT_CONNECTED"},
+	}),
+	"GET /api/v1/admin/audit-logs": scoped(models.ScopeAdmin, openapi.Route{
+		Tag:     "Admin",
+		Summary: "List audit log entries, newest first",
+		Query: []openapi.Param{
+			{Name: "limit", Type: "integer", Description: "1 to 1000, 100 by default"},
+			{Name: "action"},
+			{Name: "user_id", Type: "integer"},
+		},
+		Response: models.AuditLog{},
+		List:     true,
+		Errors:   []string{"INVALID_USER_ID", "AUDIT_FETCH_ERROR", "DB_NOT_CONNECTED"},
+	}),
+}
+
+// opsRoutes serve operators rather than API clients and are left out of
+// the reference
+var opsRoutes = map[string]bool{
+	"GET /metrics": true,
+}
+
+// docTags describes the tags in display order
+var docTags = [][2]string{
+	{"Service", "Service information and this reference"},
+	{"Auth", "Accounts and tokens"},
+	{"Inference", "Bean image classification"},
+	{"Origins", "Species origins, imports and exports"},
+	{"Species", "Sensory profiles, comparisons and the flavor wheel"},
+	{"Geo", "Geocoding, growing suitability, harvest calendar and scan heatmap"},
+	{"Usage", "Metered usage"},
+	{"Tiles", "Vector tiles and basemaps"},
+	{"Admin", "Administration"},
+}
+
+// docsPage renders the reference with Redoc
+var docsPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>{{.Title}}</title>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+<redoc spec-url="{{.SpecURL}}"></redoc>
+<script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`))
+
+// DocsHandler serves the OpenAPI document of the registered routes
+type DocsHandler struct {
+	once sync.Once
+	json []byte
+	yaml []byte
+	err  error
+}
+
+// NewDocsHandler creates a new docs handler
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{}
+}
+
+// GetPage returns the HTML API reference
+func (h *DocsHandler) GetPage(c *fiber.Ctx) error {
+	var buf strings.Builder
+	err := docsPage.Execute(&buf, struct{ Title, SpecURL string }{
+		Title:   config.Get().AppName + " API",
+		SpecURL: strings.TrimSuffix(c.Path(), "/") + "/openapi.json",
+	})
+	if err != nil {
+		return apperr.Internal("DOCS_ERROR", "Failed to build API docs").WithCause(err)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(buf.String())
+}
+
+// GetSpecJSON returns the OpenAPI document as JSON
+func (h *DocsHandler) GetSpecJSON(c *fiber.Ctx) error {
+	if err := h.build(c.App()); err != nil {
+		return apperr.Internal("DOCS_ERROR", "Failed to build API docs").WithCause(err)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(h.json)
+}
+
+// GetSpecYAML returns the OpenAPI document as YAML
+func (h *DocsHandler) GetSpecYAML(c *fiber.Ctx) error {
+	if err := h.build(c.App()); err != nil {
+		return apperr.Internal("DOCS_ERROR", "Failed to build API docs").WithCause(err)
+	}
+	c.Set(fiber.HeaderContentType, "application/yaml; charset=utf-8")
+	return c.Send(h.yaml)
+}
+
+// build encodes the document once; every route is registered by the
+// time the first request arrives
+func (h *DocsHandler) build(app *fiber.App) error {
+	h.once.Do(func() {
+		doc, drift := buildDocument(app)
+		for _, problem := range drift {
+			log.Warn().Msg(problem)
+		}
+		if h.json, h.err = doc.JSON(); h.err != nil {
+			return
+		}
+		h.yaml, h.err = doc.YAML()
+	})
+	return h.err
+}
+
+// isLegacyAlias reports whether path is an unversioned alias of a v1 route
+func isLegacyAlias(method, path string) bool {
+	rest, ok := strings.CutPrefix(path, legacyAPIPrefix+"/")
+	if !ok {
+		return false
+	}
+	_, documented := routeDocs[method+" "+apiV1Prefix+"/"+rest]
+	return documented
+}
+
+// CheckDocs returns where the API docs drift from the routes registered on
+// app: routes and error codes they miss and routes they describe in vain.
+// It is empty when the docs are complete.
+func CheckDocs(app *fiber.App) []string {
+	_, drift := buildDocument(app)
+	return drift
+}
+
+// buildDocument documents the routes registered on app with routeDocs,
+// also returning where the annotations drift from the app
+func buildDocument(app *fiber.App) (*openapi.Document, []string) {
+	cfg := config.Get()
+	b := openapi.NewBuilder(openapi.Info{
+		Title:   cfg.AppName + " API",
+		Version: cfg.AppVersion,
+		Description: "Coffee bean classification with species origins, sensory profiles and maps. Errors share one body whose code identifies the error.\n\n" +
+			"The unversioned /api routes are deprecated aliases of /api/v1, announced with the Deprecation and Sunset headers.",
+	}, apperr.ErrorBody{}, errorStatus)
+
+	b.AddSecurityScheme(schemeBearer, &openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "Access token from /api/v1/auth/login. API keys are also accepted as bearer tokens.",
+	})
+	b.AddSecurityScheme(schemeAPIKey, &openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "header",
+		Name:        "X-API-Key",
+		Description: "API key created with the apikey command, also accepted as the api_key query parameter",
+	})
+	b.AddHeader("RateLimit-Policy", "string", "Requests allowed per window and the window in seconds")
+	b.AddHeader("RateLimit-Limit", "integer", "Requests allowed in the current window")
+	b.AddHeader("RateLimit-Remaining", "integer", "Requests left in the current window")
+	b.AddHeader("RateLimit-Reset", "integer", "Seconds until the window resets")
+	b.AddHeader(middleware.HeaderQuotaLimit, "integer", "Monthly quota of the caller")
+	b.AddHeader(middleware.HeaderQuotaRemaining, "integer", "Calls left this month")
+	b.AddHeader(middleware.HeaderQuotaReset, "string", "Date the quota resets")
+	for _, tag := range docTags {
+		b.AddTag(tag[0], tag[1])
+	}
+
+	var drift []string
+	registered := make(map[string]bool)
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		if registered[key] || opsRoutes[key] {
+			continue
+		}
+		registered[key] = true
+
+		r, ok := routeDocs[key]
+		if !ok && isLegacyAlias(route.Method, route.Path) {
+			continue
+		}
+		if !ok {
+			drift = append(drift, fmt.Sprintf("Route %s is missing from the API docs", key))
+		}
+		if strings.HasPrefix(route.Path, "/api/") {
+			r.Headers = append(append([]string{}, rateLimitHeaders...), r.Headers...)
+			r.Errors = append(append([]string{}, r.Errors...), "RATE_LIMITED")
+		}
+		if err := b.Add(route.Method, route.Path, r); err != nil {
+			drift = append(drift, fmt.Sprintf("Failed to document route: %v", err))
+		}
+	}
+
+	var stale []string
+	for key := range routeDocs {
+		if !registered[key] {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(stale)
+	for _, key := range stale {
+		drift = append(drift, fmt.Sprintf("API docs describe route %s, which is not registered", key))
+	}
+
+	translated := i18n.Codes("id")
+	for _, code := range translated {
+		if _, ok := errorStatus[code]; !ok {
+			drift = append(drift, fmt.Sprintf("Error code %s is missing from the API docs", code))
+		}
+	}
+	var documented []string
+	for code := range errorStatus {
+		if !slices.Contains(translated, code) {
+			documented = append(documented, code)
+		}
+	}
+	sort.Strings(documented)
+	for _, code := range documented {
+		drift = append(drift, fmt.Sprintf("API docs describe error code %s, which has no message", code))
+	}
+
+	return b.Document(), drift
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/beanspect/backend-service/internal/i18n"
+)
+
+// TestErrorCodesDocumented checks that every error code the service
+// raises has a status in the API docs and a message
+func TestErrorCodesDocumented(t *testing.T) {
+	translated := i18n.Codes("id")
+	for code, pos := range raisedCodes(t, "../..") {
+		if _, ok := errorStatus[code]; !ok {
+			t.Errorf("%s: error code %s is missing from the API docs", pos, code)
+		}
+		if !slices.Contains(translated, code) {
+			t.Errorf("%s: error code %s has no message", pos, code)
+		}
+	}
+}
+
+// raisedCodes returns the error codes passed as literals to the apperr
+// constructors in the Go sources under root, with where each is raised
+func raisedCodes(t *testing.T, root string) map[string]string {
+	t.Helper()
+	fset := token.NewFileSet()
+	codes := make(map[string]string)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "apperr" || sel.Sel.Name == "As" {
+				return true
+			}
+
+			// New takes the status ahead of the code
+			args := call.Args
+			if sel.Sel.Name == "New" && len(args) > 0 {
+				args = args[1:]
+			}
+			if len(args) == 0 {
+				return true
+			}
+			if lit, ok := args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				code, _ := strconv.Unquote(lit.Value)
+				codes[code] = fset.Position(lit.Pos()).String()
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) == 0 {
+		t.Fatal("found no error codes, the sources were not scanned")
+	}
+	return codes
+}
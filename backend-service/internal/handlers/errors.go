@@ -0,0 +1,7 @@
+package handlers
+
+import "github.com/beanspect/backend-service/internal/apperr"
+
+// errDBNotConnected is returned by handlers that need the database while
+// it is not connected
+var errDBNotConnected = apperr.Unavailable("DB_NOT_CONNECTED", "Database connection not available")
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/geocode"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GeoHandler handles geographic lookups
+type GeoHandler struct{}
+
+// NewGeoHandler creates a new geo handler
+func NewGeoHandler() *GeoHandler {
+	return &GeoHandler{}
+}
+
+// Reverse returns the country and first-level region containing the
+// lat and lng query parameters
+func (h *GeoHandler) Reverse(c *fiber.Ctx) error {
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil || math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return apperr.BadRequest("INVALID_COORDINATES", "%s must be a number between %d and %d", "lat", -90, 90)
+	}
+	lng, err := strconv.ParseFloat(c.Query("lng"), 64)
+	if err != nil || math.IsNaN(lng) || lng < -180 || lng > 180 {
+		return apperr.BadRequest("INVALID_COORDINATES", "%s must be a number between %d and %d", "lng", -180, 180)
+	}
+
+	g := geocode.Get()
+	if g == nil {
+		return apperr.Unavailable("GEOCODER_NOT_LOADED", "Reverse geocoding is not available")
+	}
+
+	place := g.Reverse(lat, lng)
+	if place == nil {
+		return apperr.NotFound("LOCATION_NOT_FOUND", "No country or region found at %.5f, %.5f", lat, lng)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": place,
+	})
+}
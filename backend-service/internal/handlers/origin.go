@@ -1,40 +1,60 @@
 package handlers
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/apperr"
 	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/export"
+	"github.com/beanspect/backend-service/internal/importer"
+	"github.com/beanspect/backend-service/internal/middleware"
 	"github.com/beanspect/backend-service/internal/models"
+	"github.com/beanspect/backend-service/internal/tiles"
 	"github.com/gofiber/fiber/v2"
-	"github.com/rs/zerolog/log"
 )
 
 // OriginHandler handles species origin requests
-type OriginHandler struct{}
+type OriginHandler struct {
+	countries *tiles.Cache
+}
 
 // NewOriginHandler creates a new origin handler
 func NewOriginHandler() *OriginHandler {
-	return &OriginHandler{}
+	h := &OriginHandler{
+		countries: tiles.NewCache(countriesCacheSize),
+	}
+	for _, table := range []string{"species_origins", "country_productions"} {
+		database.OnTableChange(table, func() {
+			h.countries.PurgeLayer(countriesCacheLayer)
+		})
+	}
+	return h
 }
 
-// GetAllOrigins returns all species origins
+// GetAllOrigins returns all species origins, optionally filtered by
+// caffeine and altitude ranges
 func (h *OriginHandler) GetAllOrigins(c *fiber.Ctx) error {
 	db := database.Get()
 	if db == nil {
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-			"error":   true,
-			"code":    "DB_NOT_CONNECTED",
-			"message": "Database connection not available",
-		})
+		return errDBNotConnected
+	}
+
+	query, err := applyRangeFilters(c, db)
+	if err != nil {
+		return apperr.BadRequest("INVALID_RANGE", "Invalid range filter: %s", err.Error())
 	}
 
 	var origins []models.SpeciesOrigin
-	if err := db.Find(&origins).Error; err != nil {
-		log.Error().Err(err).Msg("Failed to fetch species origins")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"code":    "FETCH_ERROR",
-			"message": "Failed to fetch species origins",
-		})
+	if err := query.Find(&origins).Error; err != nil {
+		return apperr.Internal("FETCH_ERROR", "Failed to fetch species origins").WithCause(err)
 	}
+	localizeOrigins(c, db, origins)
 
 	return c.JSON(fiber.Map{
 		"data":  origins,
@@ -46,32 +66,21 @@ func (h *OriginHandler) GetAllOrigins(c *fiber.Ctx) error {
 func (h *OriginHandler) GetOriginBySpecies(c *fiber.Ctx) error {
 	species := c.Params("species")
 	if species == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   true,
-			"code":    "SPECIES_REQUIRED",
-			"message": "Species parameter is required",
-		})
+		return apperr.BadRequest("SPECIES_REQUIRED", "Species parameter is required")
 	}
 
 	db := database.Get()
 	if db == nil {
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-			"error":   true,
-			"code":    "DB_NOT_CONNECTED",
-			"message": "Database connection not available",
-		})
+		return errDBNotConnected
 	}
 
 	var origin models.SpeciesOrigin
 	result := db.Where("species = ?", species).First(&origin)
 	if result.Error != nil {
-		log.Warn().Str("species", species).Msg("Species not found")
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":   true,
-			"code":    "SPECIES_NOT_FOUND",
-			"message": "Species '" + species + "' not found",
-		})
+		middleware.Log(c).Warn().Str("species", species).Msg("Species not found")
+		return apperr.NotFound("SPECIES_NOT_FOUND", "Species '%s' not found", species)
 	}
+	localizeOrigin(c, db, &origin)
 
 	return c.JSON(fiber.Map{
 		"data": origin,
@@ -82,23 +91,149 @@ func (h *OriginHandler) GetOriginBySpecies(c *fiber.Ctx) error {
 func (h *OriginHandler) GetOriginGeoJSON(c *fiber.Ctx) error {
 	db := database.Get()
 	if db == nil {
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-			"error":   true,
-			"code":    "DB_NOT_CONNECTED",
-			"message": "Database connection not available",
-		})
+		return errDBNotConnected
 	}
 
 	var origins []models.SpeciesOrigin
 	if err := db.Find(&origins).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"code":    "FETCH_ERROR",
-			"message": "Failed to fetch species origins",
-		})
+		return apperr.Internal("FETCH_ERROR", "Failed to fetch species origins").WithCause(err)
+	}
+	localizeOrigins(c, db, origins)
+
+	return c.JSON(originFeatureCollection(origins))
+}
+
+// ExportOrigins returns all species origins as a downloadable file in the
+// format selected by the format query parameter
+func (h *OriginHandler) ExportOrigins(c *fiber.Ctx) error {
+	format := strings.ToLower(c.Query("format", "csv"))
+	encoder, ok := export.Lookup(format)
+	if !ok {
+		return apperr.BadRequest("UNSUPPORTED_FORMAT", "Unsupported export format '%s', expected one of: %s", format, strings.Join(export.Formats(), ", "))
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	var origins []models.SpeciesOrigin
+	if err := db.Order("species").Find(&origins).Error; err != nil {
+		return apperr.Internal("FETCH_ERROR", "Failed to fetch species origins").WithCause(err)
 	}
 
-	// Build GeoJSON FeatureCollection
+	var buf bytes.Buffer
+	if err := encoder.Encode(&buf, origins); err != nil {
+		return apperr.Internal("EXPORT_ERROR", "Failed to export species origins").WithCause(err)
+	}
+
+	filename := fmt.Sprintf("beanspect-origins-%s.%s", time.Now().UTC().Format("20060102"), encoder.Extension())
+	c.Set(fiber.HeaderContentType, encoder.ContentType())
+	c.Attachment(filename)
+	return c.Send(buf.Bytes())
+}
+
+// ImportOrigins bulk-loads species origins from an uploaded GeoJSON
+// FeatureCollection or CSV file. The mode query parameter selects
+// dry-run, insert or upsert; the whole import runs in one transaction.
+func (h *OriginHandler) ImportOrigins(c *fiber.Ctx) error {
+	mode, err := parseImportMode(c)
+	if err != nil {
+		return err
+	}
+	format := importer.Format(strings.ToLower(c.Query("format")))
+	if format != "" && !slices.Contains(importer.Formats(), string(format)) {
+		return apperr.BadRequest("INVALID_IMPORT_FORMAT", "Unsupported import format '%s', expected one of: %s", format, strings.Join(importer.Formats(), ", "))
+	}
+
+	// Accept either a multipart upload or the file as the raw request body
+	var filename string
+	var content []byte
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			return apperr.BadRequest("FILE_OPEN_ERROR", "Failed to open uploaded file").WithCause(err)
+		}
+		defer f.Close()
+
+		content, err = io.ReadAll(f)
+		if err != nil {
+			return apperr.BadRequest("FILE_READ_ERROR", "Failed to read uploaded file").WithCause(err)
+		}
+		filename = file.Filename
+	} else {
+		content = c.Body()
+	}
+
+	if len(bytes.TrimSpace(content)) == 0 {
+		return apperr.BadRequest("IMPORT_FILE_REQUIRED", "A GeoJSON or CSV file is required")
+	}
+
+	if format == "" {
+		format = importer.DetectFormat(filename, content)
+	}
+
+	rows, err := importer.Parse(format, content)
+	if err != nil {
+		return importParseError(format, err)
+	}
+	if len(rows) == 0 {
+		return apperr.BadRequest("IMPORT_EMPTY", "Import file contains no rows")
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	result, err := importer.Run(db, rows, mode)
+	if errors.Is(err, importer.ErrInvalidRows) {
+		return apperr.Unprocessable("IMPORT_INVALID_ROWS", "%d row(s) failed validation, nothing was imported", len(result.Errors)).WithDetails(result)
+	}
+	if err != nil {
+		return apperr.Internal("IMPORT_ERROR", "Failed to import species origins").WithCause(err)
+	}
+
+	middleware.Log(c).Info().
+		Str("mode", string(mode)).
+		Str("format", string(format)).
+		Int("inserted", result.Inserted).
+		Int("updated", result.Updated).
+		Bool("committed", result.Committed).
+		Msg("Imported species origins")
+	if result.Committed {
+		recordAudit(c, db, AuditOriginsImport, filename, "inserted=%d updated=%d", result.Inserted, result.Updated)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": result,
+	})
+}
+
+// parseImportMode reads the mode query parameter of an import, dry-run by
+// default
+func parseImportMode(c *fiber.Ctx) (importer.Mode, error) {
+	raw := c.Query("mode", string(importer.ModeDryRun))
+	mode, err := importer.ParseMode(raw)
+	if err != nil {
+		return "", apperr.BadRequest("INVALID_IMPORT_MODE", "Unsupported import mode '%s', expected one of: %s", raw, strings.Join(importer.Modes(), ", "))
+	}
+	return mode, nil
+}
+
+// importParseError answers an import file that cannot be read as format.
+// The parser's reason is not localized and goes into the details.
+func importParseError(format importer.Format, err error) *apperr.Error {
+	e := apperr.BadRequest("IMPORT_PARSE_ERROR", "Import file could not be read as %s", format).WithCause(err)
+	var parseErr *importer.ParseError
+	if errors.As(err, &parseErr) {
+		e = e.WithDetails(parseErr)
+	}
+	return e
+}
+
+// originFeatureCollection builds a GeoJSON FeatureCollection of origin points
+func originFeatureCollection(origins []models.SpeciesOrigin) fiber.Map {
 	features := make([]fiber.Map, len(origins))
 	for i, origin := range origins {
 		features[i] = fiber.Map{
@@ -108,20 +243,25 @@ func (h *OriginHandler) GetOriginGeoJSON(c *fiber.Ctx) error {
 				"coordinates": []float64{origin.Longitude, origin.Latitude},
 			},
 			"properties": fiber.Map{
-				"species":        origin.Species,
-				"common_name":    origin.CommonName,
-				"country":        origin.Country,
-				"region":         origin.Region,
-				"description":    origin.Description,
-				"taste_profile":  origin.TasteProfile,
-				"caffeine_level": origin.CaffeineLevel,
-				"image_url":      origin.ImageURL,
+				"species":          origin.Species,
+				"common_name":      origin.CommonName,
+				"country":          origin.Country,
+				"region":           origin.Region,
+				"description":      origin.Description,
+				"taste_profile":    origin.TasteProfile,
+				"caffeine_level":   origin.CaffeineLevel,
+				"caffeine_min_pct": origin.CaffeineMinPct,
+				"caffeine_max_pct": origin.CaffeineMaxPct,
+				"altitude":         origin.Altitude,
+				"altitude_min_m":   origin.AltitudeMinM,
+				"altitude_max_m":   origin.AltitudeMaxM,
+				"image_url":        origin.ImageURL,
 			},
 		}
 	}
 
-	return c.JSON(fiber.Map{
+	return fiber.Map{
 		"type":     "FeatureCollection",
 		"features": features,
-	})
+	}
 }
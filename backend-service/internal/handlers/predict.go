@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"errors"
 	"io"
+	"mime/multipart"
+	"net/http"
 
+	"github.com/beanspect/backend-service/internal/apperr"
 	"github.com/beanspect/backend-service/internal/services"
+	"github.com/beanspect/backend-service/internal/tracing"
 	"github.com/gofiber/fiber/v2"
-	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // PredictHandler handles image prediction requests
@@ -22,50 +27,69 @@ func NewPredictHandler() *PredictHandler {
 
 // Predict proxies prediction requests to the inference service
 func (h *PredictHandler) Predict(c *fiber.Ctx) error {
-	// Get file from form
-	file, err := c.FormFile("file")
+	file, content, err := readImage(c)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get file from form")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   true,
-			"code":    "FILE_REQUIRED",
-			"message": "Image file is required",
-		})
+		return err
+	}
+
+	// Send to inference service
+	prediction, err := h.inferenceClient.Predict(c.UserContext(), file.Filename, content)
+	if err != nil {
+		return inferenceFailure(err)
+	}
+
+	return c.JSON(prediction)
+}
+
+// readImage parses the multipart form and reads the uploaded image
+func readImage(c *fiber.Ctx) (*multipart.FileHeader, []byte, error) {
+	_, span := tracing.Tracer().Start(c.UserContext(), "multipart.parse")
+	file, content, err := readFormFile(c, "file")
+	if err == nil {
+		span.SetAttributes(
+			attribute.String("beanspect.image.filename", file.Filename),
+			attribute.Int("beanspect.image.size", len(content)),
+		)
+	}
+	tracing.End(span, err)
+	return file, content, err
+}
+
+func readFormFile(c *fiber.Ctx, field string) (*multipart.FileHeader, []byte, error) {
+	file, err := c.FormFile(field)
+	if err != nil {
+		return nil, nil, apperr.BadRequest("FILE_REQUIRED", "Image file is required").WithCause(err)
 	}
 
-	// Open file
 	f, err := file.Open()
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to open file")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   true,
-			"code":    "FILE_OPEN_ERROR",
-			"message": "Failed to open uploaded file",
-		})
+		return nil, nil, apperr.BadRequest("FILE_OPEN_ERROR", "Failed to open uploaded file").WithCause(err)
 	}
 	defer f.Close()
 
-	// Read file content
 	content, err := io.ReadAll(f)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to read file")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   true,
-			"code":    "FILE_READ_ERROR",
-			"message": "Failed to read uploaded file",
-		})
+		return nil, nil, apperr.BadRequest("FILE_READ_ERROR", "Failed to read uploaded file").WithCause(err)
 	}
+	return file, content, nil
+}
 
-	// Send to inference service
-	prediction, err := h.inferenceClient.Predict(file.Filename, content)
-	if err != nil {
-		log.Error().Err(err).Msg("Inference service error")
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-			"error":   true,
-			"code":    "INFERENCE_ERROR",
-			"message": err.Error(),
-		})
+// inferenceFailure maps a failed prediction to the error answered to the
+// client: images the inference service rejects are the client's fault,
+// anything else means the service cannot serve predictions right now
+func inferenceFailure(err error) *apperr.Error {
+	var upstream *services.InferenceError
+	if !errors.As(err, &upstream) {
+		return apperr.Unavailable("INFERENCE_UNAVAILABLE", "Inference service is unavailable").WithCause(err)
 	}
 
-	return c.JSON(prediction)
+	if upstream.StatusCode >= http.StatusBadRequest && upstream.StatusCode < http.StatusInternalServerError && upstream.StatusCode != http.StatusTooManyRequests {
+		message := upstream.Message
+		if message == "" {
+			message = upstream.Error()
+		}
+		return apperr.BadRequest("INFERENCE_REJECTED", "Inference service rejected the image: %s", message).WithCause(err)
+	}
+	// Server errors carry the service's internals, which stay in the cause
+	return apperr.Unavailable("INFERENCE_ERROR", "Inference service failed to process the image").WithCause(err)
 }
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// rangeFilter maps a query parameter onto the numeric range columns.
+// Filters use overlap semantics: a species matches caffeine_min=1.5 when
+// its caffeine range reaches at least 1.5%.
+type rangeFilter struct {
+	param string
+	cond  string
+}
+
+var rangeFilters = []rangeFilter{
+	{"caffeine_min", "caffeine_max_pct >= ?"},
+	{"caffeine_max", "caffeine_min_pct <= ?"},
+	{"altitude_min", "altitude_max_m >= ?"},
+	{"altitude_max", "altitude_min_m <= ?"},
+}
+
+// rangeSorts are the accepted values of the sort query parameter
+var rangeSorts = map[string]string{
+	"species":   "species",
+	"caffeine":  "caffeine_min_pct",
+	"-caffeine": "caffeine_max_pct DESC",
+	"altitude":  "altitude_min_m",
+	"-altitude": "altitude_max_m DESC",
+}
+
+// applyRangeFilters narrows query by the caffeine and altitude range
+// parameters and orders it by the sort parameter
+func applyRangeFilters(c *fiber.Ctx, query *gorm.DB) (*gorm.DB, error) {
+	for _, f := range rangeFilters {
+		raw := c.Query(f.param)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", f.param)
+		}
+		query = query.Where(f.cond, v)
+	}
+
+	if sort := strings.ToLower(c.Query("sort")); sort != "" {
+		order, ok := rangeSorts[sort]
+		if !ok {
+			return nil, fmt.Errorf("unsupported sort '%s'", sort)
+		}
+		query = query.Order(order)
+	}
+	return query, nil
+}
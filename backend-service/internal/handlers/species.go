@@ -0,0 +1,533 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/beanspect/backend-service/internal/recommend"
+	"github.com/beanspect/backend-service/internal/sensory"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SpeciesHandler handles species catalog queries
+type SpeciesHandler struct{}
+
+// NewSpeciesHandler creates a new species handler
+func NewSpeciesHandler() *SpeciesHandler {
+	return &SpeciesHandler{}
+}
+
+// SpeciesData is a species origin together with its structured taste profile
+type SpeciesData struct {
+	models.SpeciesOrigin
+	Sensory *SensoryData `json:"sensory"`
+}
+
+// SensoryData is the structured taste profile of a species
+type SensoryData struct {
+	Species    string          `json:"species"`
+	Summary    string          `json:"summary"`
+	Acidity    float64         `json:"acidity"`
+	Body       float64         `json:"body"`
+	Sweetness  float64         `json:"sweetness"`
+	Bitterness float64         `json:"bitterness"`
+	FlavorTags []FlavorTagData `json:"flavor_tags"`
+}
+
+// FlavorTagData is a flavor wheel tag with its path from the inner ring
+type FlavorTagData struct {
+	Slug string   `json:"slug"`
+	Name string   `json:"name"`
+	Path []string `json:"path"`
+}
+
+// RadarDataset is one species series of a radar chart
+type RadarDataset struct {
+	Species string    `json:"species"`
+	Label   string    `json:"label"`
+	Values  []float64 `json:"values"`
+}
+
+// RadarResponse is a radar chart of sensory scores over shared axes
+type RadarResponse struct {
+	Axes     []string       `json:"axes"`
+	Min      float64        `json:"min"`
+	Max      float64        `json:"max"`
+	Datasets []RadarDataset `json:"datasets"`
+}
+
+// Bounds on the number of species in one comparison
+const (
+	minCompareSpecies = 2
+	maxCompareSpecies = 4
+)
+
+// ComparedAttribute is one row of a side-by-side species comparison
+type ComparedAttribute struct {
+	Attribute string                 `json:"attribute"`
+	Group     string                 `json:"group"` // origin, caffeine, altitude, taste
+	Values    map[string]interface{} `json:"values"`
+	Differs   bool                   `json:"differs"`
+}
+
+// ComparisonData is a side-by-side comparison of species
+type ComparisonData struct {
+	Species    []SpeciesData       `json:"species"`
+	Attributes []ComparedAttribute `json:"attributes"`
+	Flavors    FlavorOverlap       `json:"flavors"`
+	GeoJSON    fiber.Map           `json:"geojson"`
+}
+
+// FlavorOverlap splits the compared species' flavor tags into shared and unique
+type FlavorOverlap struct {
+	Shared []string            `json:"shared"`
+	Unique map[string][]string `json:"unique"`
+}
+
+// comparedAttributes are the rows of a comparison, in display order
+var comparedAttributes = []struct {
+	name  string
+	group string
+	value func(SpeciesData) interface{}
+}{
+	{"scientific_name", "origin", func(d SpeciesData) interface{} { return d.ScientificName }},
+	{"country", "origin", func(d SpeciesData) interface{} { return d.Country }},
+	{"region", "origin", func(d SpeciesData) interface{} { return d.Region }},
+	{"caffeine_level", "caffeine", func(d SpeciesData) interface{} { return d.CaffeineLevel }},
+	{"caffeine_min_pct", "caffeine", func(d SpeciesData) interface{} { return derefFloat(d.CaffeineMinPct) }},
+	{"caffeine_max_pct", "caffeine", func(d SpeciesData) interface{} { return derefFloat(d.CaffeineMaxPct) }},
+	{"altitude", "altitude", func(d SpeciesData) interface{} { return d.Altitude }},
+	{"altitude_min_m", "altitude", func(d SpeciesData) interface{} { return derefInt(d.AltitudeMinM) }},
+	{"altitude_max_m", "altitude", func(d SpeciesData) interface{} { return derefInt(d.AltitudeMaxM) }},
+	{"taste_profile", "taste", func(d SpeciesData) interface{} { return d.TasteProfile }},
+	{"acidity", "taste", func(d SpeciesData) interface{} { return sensoryScore(d.Sensory, 0) }},
+	{"body", "taste", func(d SpeciesData) interface{} { return sensoryScore(d.Sensory, 1) }},
+	{"sweetness", "taste", func(d SpeciesData) interface{} { return sensoryScore(d.Sensory, 2) }},
+	{"bitterness", "taste", func(d SpeciesData) interface{} { return sensoryScore(d.Sensory, 3) }},
+	{"flavor_tags", "taste", func(d SpeciesData) interface{} { return flavorSlugs(d.Sensory) }},
+}
+
+// ListSpecies returns catalog species with their sensory profiles.
+// The flavor query parameter filters by a flavor wheel tag, matching
+// species tagged with that tag or any tag beneath it on the wheel.
+// Caffeine and altitude range filters apply as on the origins listing.
+func (h *SpeciesHandler) ListSpecies(c *fiber.Ctx) error {
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	query, err := applyRangeFilters(c, db)
+	if err != nil {
+		return apperr.BadRequest("INVALID_RANGE", "Invalid range filter: %s", err.Error())
+	}
+	query = query.Order("species")
+	if flavor := strings.ToLower(c.Query("flavor")); flavor != "" {
+		idx, err := loadFlavorIndex(db)
+		if err != nil {
+			return apperr.Internal("FETCH_ERROR", "Failed to fetch species origins").WithCause(err)
+		}
+		tag, ok := idx.Lookup(flavor)
+		if !ok {
+			return apperr.BadRequest("FLAVOR_NOT_FOUND", "Flavor tag '%s' not found", flavor)
+		}
+
+		tagged := db.Table("sensory_profiles").
+			Select("sensory_profiles.species").
+			Joins("JOIN sensory_profile_flavor_tags spft ON spft.sensory_profile_id = sensory_profiles.id").
+			Where("spft.flavor_tag_id IN ?", idx.Descendants(tag.ID))
+		query = query.Where("species IN (?)", tagged)
+	}
+
+	var origins []models.SpeciesOrigin
+	if err := query.Find(&origins).Error; err != nil {
+		return apperr.Internal("FETCH_ERROR", "Failed to fetch species origins").WithCause(err)
+	}
+	localizeOrigins(c, db, origins)
+
+	data, err := withSensory(db, origins)
+	if err != nil {
+		return apperr.Internal("FETCH_ERROR", "Failed to fetch species origins").WithCause(err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  data,
+		"count": len(data),
+	})
+}
+
+// CompareSpecies returns a side-by-side comparison of two to four species
+// from the comma-separated species query parameter, flagging attributes
+// whose values differ, together with a GeoJSON of their origins
+func (h *SpeciesHandler) CompareSpecies(c *fiber.Ctx) error {
+	names := uniqueList(splitList(c.Query("species")))
+	if len(names) < minCompareSpecies || len(names) > maxCompareSpecies {
+		return apperr.BadRequest("INVALID_COMPARISON", "Compare between %d and %d distinct species", minCompareSpecies, maxCompareSpecies)
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	var origins []models.SpeciesOrigin
+	if err := db.Where("species IN ?", names).Find(&origins).Error; err != nil {
+		return apperr.Internal("FETCH_ERROR", "Failed to fetch species origins").WithCause(err)
+	}
+
+	// Keep the requested order
+	byName := make(map[string]models.SpeciesOrigin, len(origins))
+	for _, o := range origins {
+		byName[o.Species] = o
+	}
+	ordered := make([]models.SpeciesOrigin, 0, len(names))
+	for _, name := range names {
+		o, ok := byName[name]
+		if !ok {
+			return apperr.NotFound("SPECIES_NOT_FOUND", "Species '%s' not found", name)
+		}
+		ordered = append(ordered, o)
+	}
+	localizeOrigins(c, db, ordered)
+
+	data, err := withSensory(db, ordered)
+	if err != nil {
+		return apperr.Internal("FETCH_ERROR", "Failed to fetch species origins").WithCause(err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": ComparisonData{
+			Species:    data,
+			Attributes: compareAttributes(data),
+			Flavors:    compareFlavors(data),
+			GeoJSON:    originFeatureCollection(ordered),
+		},
+	})
+}
+
+// GetSimilar ranks the other catalog species by similarity to a species
+// over taste, caffeine, altitude and region, with the reasons for each match
+func (h *SpeciesHandler) GetSimilar(c *fiber.Ctx) error {
+	species := strings.ToLower(c.Params("species"))
+	limit := c.QueryInt("limit", 0)
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	matches, err := findSimilar(c, db, species, limit)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperr.NotFound("SPECIES_NOT_FOUND", "Species '%s' not found", species)
+	}
+	if err != nil {
+		return apperr.Internal("FETCH_ERROR", "Failed to fetch species origins").WithCause(err)
+	}
+
+	return c.JSON(fiber.Map{
+		"species": species,
+		"data":    matches,
+		"count":   len(matches),
+	})
+}
+
+// GetFlavorWheel returns the flavor wheel hierarchy
+func (h *SpeciesHandler) GetFlavorWheel(c *fiber.Ctx) error {
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	idx, err := loadFlavorIndex(db)
+	if err != nil {
+		return apperr.Internal("FLAVOR_FETCH_ERROR", "Failed to fetch flavor wheel").WithCause(err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": idx.Tree(),
+	})
+}
+
+// GetRadar returns radar-chart-ready sensory scores for the species in
+// the comma-separated species query parameter, or for every species
+func (h *SpeciesHandler) GetRadar(c *fiber.Ctx) error {
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	query := db.Order("species")
+	if list := splitList(c.Query("species")); len(list) > 0 {
+		query = query.Where("species IN ?", list)
+	}
+
+	var origins []models.SpeciesOrigin
+	if err := query.Find(&origins).Error; err != nil {
+		return apperr.Internal("FETCH_ERROR", "Failed to fetch species origins").WithCause(err)
+	}
+	localizeOrigins(c, db, origins)
+
+	var profiles []models.SensoryProfile
+	if err := db.Where("species IN ?", speciesNames(origins)).Find(&profiles).Error; err != nil {
+		return apperr.Internal("FETCH_ERROR", "Failed to fetch species origins").WithCause(err)
+	}
+	bySpecies := make(map[string]models.SensoryProfile, len(profiles))
+	for _, p := range profiles {
+		bySpecies[p.Species] = p
+	}
+
+	datasets := make([]RadarDataset, 0, len(origins))
+	for _, o := range origins {
+		p, ok := bySpecies[o.Species]
+		if !ok {
+			continue
+		}
+		datasets = append(datasets, RadarDataset{
+			Species: o.Species,
+			Label:   o.CommonName,
+			Values:  sensory.Values(p),
+		})
+	}
+
+	return c.JSON(RadarResponse{
+		Axes:     sensory.Axes,
+		Min:      0,
+		Max:      sensory.MaxScore,
+		Datasets: datasets,
+	})
+}
+
+// compareAttributes builds one comparison row per attribute
+func compareAttributes(data []SpeciesData) []ComparedAttribute {
+	rows := make([]ComparedAttribute, len(comparedAttributes))
+	for i, attr := range comparedAttributes {
+		row := ComparedAttribute{
+			Attribute: attr.name,
+			Group:     attr.group,
+			Values:    make(map[string]interface{}, len(data)),
+		}
+		var first string
+		for j, d := range data {
+			v := attr.value(d)
+			row.Values[d.Species] = v
+			if key := fmt.Sprint(v); j == 0 {
+				first = key
+			} else if key != first {
+				row.Differs = true
+			}
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// compareFlavors finds the flavor tags every species shares and those
+// only one species has
+func compareFlavors(data []SpeciesData) FlavorOverlap {
+	counts := make(map[string]int)
+	for _, d := range data {
+		for _, slug := range flavorSlugs(d.Sensory) {
+			counts[slug]++
+		}
+	}
+
+	overlap := FlavorOverlap{Shared: []string{}, Unique: make(map[string][]string, len(data))}
+	for _, d := range data {
+		unique := []string{}
+		for _, slug := range flavorSlugs(d.Sensory) {
+			if counts[slug] == 1 {
+				unique = append(unique, slug)
+			}
+		}
+		overlap.Unique[d.Species] = unique
+	}
+	for slug, n := range counts {
+		if n == len(data) {
+			overlap.Shared = append(overlap.Shared, slug)
+		}
+	}
+	sort.Strings(overlap.Shared)
+	return overlap
+}
+
+// flavorSlugs returns the sorted flavor tag slugs of a profile
+func flavorSlugs(s *SensoryData) []string {
+	if s == nil {
+		return nil
+	}
+	slugs := make([]string, len(s.FlavorTags))
+	for i, t := range s.FlavorTags {
+		slugs[i] = t.Slug
+	}
+	sort.Strings(slugs)
+	return slugs
+}
+
+// sensoryScore returns the score on the given radar axis, or nil without a profile
+func sensoryScore(s *SensoryData, axis int) interface{} {
+	if s == nil {
+		return nil
+	}
+	return []float64{s.Acidity, s.Body, s.Sweetness, s.Bitterness}[axis]
+}
+
+func derefFloat(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func derefInt(v *int) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+// findSimilar ranks the catalog against species, keeping at most limit
+// matches when limit is positive. It returns gorm.ErrRecordNotFound for
+// an unknown species.
+func findSimilar(c *fiber.Ctx, db *gorm.DB, species string, limit int) ([]recommend.Match, error) {
+	var origins []models.SpeciesOrigin
+	if err := db.Order("species").Find(&origins).Error; err != nil {
+		return nil, err
+	}
+	localizeOrigins(c, db, origins)
+
+	var profiles []models.SensoryProfile
+	if err := db.Preload("FlavorTags").Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	bySpecies := make(map[string]*models.SensoryProfile, len(profiles))
+	for i := range profiles {
+		bySpecies[profiles[i].Species] = &profiles[i]
+	}
+
+	idx, err := loadFlavorIndex(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref *recommend.Species
+	candidates := make([]recommend.Species, len(origins))
+	for i, o := range origins {
+		candidates[i] = recommend.Species{Origin: o, Profile: bySpecies[o.Species]}
+		if o.Species == species {
+			ref = &candidates[i]
+		}
+	}
+	if ref == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	matches := recommend.Rank(*ref, candidates, idx)
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// withSensory pairs each origin with its sensory profile
+func withSensory(db *gorm.DB, origins []models.SpeciesOrigin) ([]SpeciesData, error) {
+	profiles, err := loadSensory(db, origins)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]SpeciesData, len(origins))
+	for i, o := range origins {
+		data[i] = SpeciesData{SpeciesOrigin: o, Sensory: profiles[o.Species]}
+	}
+	return data, nil
+}
+
+// loadSensory fetches the sensory profiles of origins keyed by species.
+// The origin's (possibly localized) taste profile text becomes the summary.
+func loadSensory(db *gorm.DB, origins []models.SpeciesOrigin) (map[string]*SensoryData, error) {
+	result := make(map[string]*SensoryData, len(origins))
+	if len(origins) == 0 {
+		return result, nil
+	}
+
+	var profiles []models.SensoryProfile
+	if err := db.Preload("FlavorTags").Where("species IN ?", speciesNames(origins)).Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	if len(profiles) == 0 {
+		return result, nil
+	}
+
+	idx, err := loadFlavorIndex(db)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[string]string, len(origins))
+	for _, o := range origins {
+		summaries[o.Species] = o.TasteProfile
+	}
+
+	for _, p := range profiles {
+		tags := make([]FlavorTagData, len(p.FlavorTags))
+		for i, t := range p.FlavorTags {
+			tags[i] = FlavorTagData{Slug: t.Slug, Name: t.Name, Path: idx.Path(t.ID)}
+		}
+		result[p.Species] = &SensoryData{
+			Species:    p.Species,
+			Summary:    summaries[p.Species],
+			Acidity:    p.Acidity,
+			Body:       p.Body,
+			Sweetness:  p.Sweetness,
+			Bitterness: p.Bitterness,
+			FlavorTags: tags,
+		}
+	}
+	return result, nil
+}
+
+func loadFlavorIndex(db *gorm.DB) (*sensory.Index, error) {
+	var tags []models.FlavorTag
+	if err := db.Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return sensory.NewIndex(tags), nil
+}
+
+func speciesNames(origins []models.SpeciesOrigin) []string {
+	names := make([]string, len(origins))
+	for i, o := range origins {
+		names[i] = o.Species
+	}
+	return names
+}
+
+// splitList splits a comma-separated query value into trimmed, lowercase items
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// uniqueList drops repeated items, keeping the first occurrence
+func uniqueList(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/dem"
+	"github.com/beanspect/backend-service/internal/geocode"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/beanspect/backend-service/internal/suitability"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// Elevation sources reported with a suitability estimate
+const (
+	ElevationSupplied = "supplied"
+	ElevationDEM      = "dem"
+)
+
+// SuitabilityHandler handles growing suitability estimates
+type SuitabilityHandler struct {
+	dem *dem.Model
+}
+
+// NewSuitabilityHandler creates a new suitability handler, opening the
+// elevation model when one is configured
+func NewSuitabilityHandler() *SuitabilityHandler {
+	cfg := config.Get()
+	h := &SuitabilityHandler{}
+
+	if cfg.DEMPath != "" {
+		model, err := dem.Open(cfg.DEMPath)
+		if err != nil {
+			log.Error().Err(err).Str("path", cfg.DEMPath).Msg("Failed to open elevation model")
+		} else {
+			h.dem = model
+		}
+	}
+
+	return h
+}
+
+// SuitabilityRequest is the body of a suitability estimate
+type SuitabilityRequest struct {
+	Latitude         *float64 `json:"latitude"`
+	Longitude        *float64 `json:"longitude"`
+	ElevationM       *float64 `json:"elevation_m"`
+	MeanTempC        *float64 `json:"mean_temp_c"`
+	AnnualRainfallMm *float64 `json:"annual_rainfall_mm"`
+}
+
+// SiteData describes the evaluated site
+type SiteData struct {
+	Latitude         float64        `json:"latitude"`
+	Longitude        float64        `json:"longitude"`
+	ElevationM       *float64       `json:"elevation_m"`
+	ElevationSource  string         `json:"elevation_source,omitempty"`
+	MeanTempC        *float64       `json:"mean_temp_c"`
+	AnnualRainfallMm *float64       `json:"annual_rainfall_mm"`
+	Place            *geocode.Place `json:"place,omitempty"`
+}
+
+// SuitabilityData is a site with its ranked species
+type SuitabilityData struct {
+	Site    SiteData             `json:"site"`
+	Results []suitability.Result `json:"results"`
+}
+
+// Evaluate scores every catalog species for a growing site. Elevation is
+// read from the elevation model when it is not supplied.
+func (h *SuitabilityHandler) Evaluate(c *fiber.Ctx) error {
+	var req SuitabilityRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.BadRequest("INVALID_REQUEST", "Invalid request body")
+	}
+
+	if req.Latitude == nil || *req.Latitude < -90 || *req.Latitude > 90 {
+		return apperr.BadRequest("INVALID_COORDINATES", "%s must be a number between %d and %d", "latitude", -90, 90)
+	}
+	if req.Longitude == nil || *req.Longitude < -180 || *req.Longitude > 180 {
+		return apperr.BadRequest("INVALID_COORDINATES", "%s must be a number between %d and %d", "longitude", -180, 180)
+	}
+	if req.ElevationM != nil && (*req.ElevationM < -500 || *req.ElevationM > 9000) {
+		return apperr.BadRequest("INVALID_SITE", "Invalid growing site: %s", "elevation_m must be between -500 and 9000")
+	}
+	if req.MeanTempC != nil && (*req.MeanTempC < -50 || *req.MeanTempC > 50) {
+		return apperr.BadRequest("INVALID_SITE", "Invalid growing site: %s", "mean_temp_c must be between -50 and 50")
+	}
+	if req.AnnualRainfallMm != nil && (*req.AnnualRainfallMm < 0 || *req.AnnualRainfallMm > 15000) {
+		return apperr.BadRequest("INVALID_SITE", "Invalid growing site: %s", "annual_rainfall_mm must be between 0 and 15000")
+	}
+
+	site := SiteData{
+		Latitude:         *req.Latitude,
+		Longitude:        *req.Longitude,
+		ElevationM:       req.ElevationM,
+		MeanTempC:        req.MeanTempC,
+		AnnualRainfallMm: req.AnnualRainfallMm,
+	}
+
+	if site.ElevationM != nil {
+		site.ElevationSource = ElevationSupplied
+	} else if h.dem != nil {
+		elevation, err := h.dem.Elevation(site.Latitude, site.Longitude)
+		switch {
+		case err == nil:
+			elevation = math.Round(elevation)
+			site.ElevationM = &elevation
+			site.ElevationSource = ElevationDEM
+		case errors.Is(err, dem.ErrOutOfBounds), errors.Is(err, dem.ErrNoData):
+			// Scored without the altitude constraint
+		default:
+			return apperr.Internal("ELEVATION_UNAVAILABLE", "Elevation could not be determined").WithCause(err)
+		}
+	}
+
+	if g := geocode.Get(); g != nil {
+		site.Place = g.Reverse(site.Latitude, site.Longitude)
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	var origins []models.SpeciesOrigin
+	if err := db.Order("species").Find(&origins).Error; err != nil {
+		return apperr.Internal("SUITABILITY_ERROR", "Failed to estimate growing suitability").WithCause(err)
+	}
+
+	results := suitability.Rank(suitability.Site{
+		Latitude:   site.Latitude,
+		Longitude:  site.Longitude,
+		ElevationM: site.ElevationM,
+		TempC:      site.MeanTempC,
+		RainfallMm: site.AnnualRainfallMm,
+	}, origins)
+
+	return c.JSON(fiber.Map{
+		"data": SuitabilityData{
+			Site:    site,
+			Results: results,
+		},
+		"count": len(results),
+	})
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/geo"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/beanspect/backend-service/internal/tiles"
+	"github.com/gofiber/fiber/v2"
+)
+
+// MVTContentType is the media type of Mapbox Vector Tiles
+const MVTContentType = "application/vnd.mapbox-vector-tile"
+
+// TileHandler serves vector tiles and TileJSON for the map layers
+type TileHandler struct {
+	sources map[string]*tiles.Source
+	names   []string
+	cache   *tiles.Cache
+}
+
+// NewTileHandler creates a new tile handler with the built-in layers
+func NewTileHandler() *TileHandler {
+	cfg := config.Get()
+	h := &TileHandler{
+		sources: make(map[string]*tiles.Source),
+		cache:   tiles.NewCache(cfg.TileCacheSize),
+	}
+
+	h.Register(originTileSource(), models.SpeciesOrigin{}.TableName())
+
+	return h
+}
+
+// Register adds a tile layer. Cached tiles of the layer are purged
+// whenever one of the given tables is written to.
+func (h *TileHandler) Register(src *tiles.Source, tables ...string) {
+	h.sources[src.Name] = src
+	h.names = append(h.names, src.Name)
+
+	name := src.Name
+	for _, table := range tables {
+		database.OnTableChange(table, func() {
+			h.cache.PurgeLayer(name)
+		})
+	}
+}
+
+// TileJSON is a TileJSON 3.0.0 descriptor for a vector tile layer
+type TileJSON struct {
+	TileJSON     string        `json:"tilejson"`
+	Name         string        `json:"name"`
+	Description  string        `json:"description"`
+	Attribution  string        `json:"attribution,omitempty"`
+	Version      string        `json:"version"`
+	Scheme       string        `json:"scheme"`
+	Format       string        `json:"format,omitempty"`
+	Tiles        []string      `json:"tiles"`
+	MinZoom      int           `json:"minzoom"`
+	MaxZoom      int           `json:"maxzoom"`
+	Bounds       []float64     `json:"bounds"`
+	Center       []float64     `json:"center,omitempty"`
+	VectorLayers []VectorLayer `json:"vector_layers,omitempty"`
+}
+
+// VectorLayer describes a layer inside a vector tile
+type VectorLayer struct {
+	ID          string            `json:"id"`
+	Description string            `json:"description"`
+	MinZoom     int               `json:"minzoom"`
+	MaxZoom     int               `json:"maxzoom"`
+	Fields      map[string]string `json:"fields"`
+}
+
+// ListLayers returns the TileJSON descriptors of every tile layer
+func (h *TileHandler) ListLayers(c *fiber.Ctx) error {
+	layers := make([]TileJSON, 0, len(h.names))
+	for _, name := range h.names {
+		layers = append(layers, h.tileJSON(c, h.sources[name]))
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  layers,
+		"count": len(layers),
+	})
+}
+
+// GetTileJSON returns the TileJSON descriptor of a single layer
+func (h *TileHandler) GetTileJSON(c *fiber.Ctx) error {
+	src, ok := h.sources[c.Params("layer")]
+	if !ok {
+		return layerNotFound(c)
+	}
+	return c.JSON(h.tileJSON(c, src))
+}
+
+// GetTile returns one Mapbox Vector Tile of a layer
+func (h *TileHandler) GetTile(c *fiber.Ctx) error {
+	layer := c.Params("layer")
+	src, ok := h.sources[layer]
+	if !ok {
+		return layerNotFound(c)
+	}
+
+	z, errZ := c.ParamsInt("z")
+	x, errX := c.ParamsInt("x")
+	y, errY := c.ParamsInt("y")
+	if errZ != nil || errX != nil || errY != nil || !geo.ValidTile(z, x, y) {
+		return apperr.BadRequest("INVALID_TILE", "Tile coordinates are out of range")
+	}
+
+	key := tiles.Key(layer, z, x, y)
+	tile, ok := h.cache.Get(key)
+	if !ok {
+		if database.Get() == nil {
+			return errDBNotConnected
+		}
+
+		data, err := tiles.Render(src, z, x, y)
+		if err != nil {
+			return apperr.Internal("TILE_RENDER_ERROR", "Failed to render tile").WithCause(err)
+		}
+		tile = tiles.NewCachedTile(data)
+		h.cache.Set(key, tile)
+	}
+
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", config.Get().TileMaxAge))
+	c.Set(fiber.HeaderETag, tile.ETag)
+	if match := c.Get(fiber.HeaderIfNoneMatch); match == "*" || strings.Contains(match, tile.ETag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, MVTContentType)
+	return c.Send(tile.Data)
+}
+
+func (h *TileHandler) tileJSON(c *fiber.Ctx, src *tiles.Source) TileJSON {
+	cfg := config.Get()
+	return TileJSON{
+		TileJSON:    "3.0.0",
+		Name:        src.Name,
+		Description: src.Description,
+		Version:     cfg.AppVersion,
+		Scheme:      "xyz",
+		Tiles:       []string{c.BaseURL() + apiV1Prefix + "/tiles/" + src.Name + "/{z}/{x}/{y}.mvt"},
+		MinZoom:     src.MinZoom,
+		MaxZoom:     src.MaxZoom,
+		Bounds:      []float64{-180, -geo.MaxMercatorLat, 180, geo.MaxMercatorLat},
+		VectorLayers: []VectorLayer{{
+			ID:          src.Name,
+			Description: src.Description,
+			MinZoom:     src.MinZoom,
+			MaxZoom:     src.MaxZoom,
+			Fields:      src.Fields,
+		}},
+	}
+}
+
+func layerNotFound(c *fiber.Ctx) error {
+	return apperr.NotFound("LAYER_NOT_FOUND", "Tile layer '%s' not found", c.Params("layer"))
+}
+
+// originTileSource exposes species_origins as a point layer
+func originTileSource() *tiles.Source {
+	return &tiles.Source{
+		Name:        "origins",
+		Description: "Coffee species origin locations",
+		MinZoom:     0,
+		MaxZoom:     geo.MaxZoom,
+		Fields: map[string]string{
+			"species":         "String",
+			"common_name":     "String",
+			"scientific_name": "String",
+			"country":         "String",
+			"region":          "String",
+			"caffeine_level":  "String",
+			"altitude":        "String",
+			"image_url":       "String",
+		},
+		Fetch: func(bounds geo.BBox, z int) ([]tiles.SourceFeature, error) {
+			var origins []models.SpeciesOrigin
+			err := database.Get().
+				Where("longitude BETWEEN ? AND ?", bounds.MinLng, bounds.MaxLng).
+				Where("latitude BETWEEN ? AND ?", bounds.MinLat, bounds.MaxLat).
+				Order("id").
+				Find(&origins).Error
+			if err != nil {
+				return nil, err
+			}
+
+			features := make([]tiles.SourceFeature, len(origins))
+			for i, origin := range origins {
+				features[i] = tiles.SourceFeature{
+					ID:    uint64(origin.ID),
+					Point: &geo.Point{Lng: origin.Longitude, Lat: origin.Latitude},
+					Properties: map[string]interface{}{
+						"species":         origin.Species,
+						"common_name":     origin.CommonName,
+						"scientific_name": origin.ScientificName,
+						"country":         origin.Country,
+						"region":          origin.Region,
+						"caffeine_level":  origin.CaffeineLevel,
+						"altitude":        origin.Altitude,
+						"image_url":       origin.ImageURL,
+					},
+				}
+			}
+			return features, nil
+		},
+	}
+}
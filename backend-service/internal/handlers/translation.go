@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/i18n"
+	"github.com/beanspect/backend-service/internal/middleware"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TranslationHandler manages localized species content
+type TranslationHandler struct{}
+
+// NewTranslationHandler creates a new translation handler
+func NewTranslationHandler() *TranslationHandler {
+	return &TranslationHandler{}
+}
+
+// TranslationRequest is the body for creating or updating a translation
+type TranslationRequest struct {
+	Species string `json:"species"`
+	Field   string `json:"field"`
+	Locale  string `json:"locale"`
+	Value   string `json:"value"`
+}
+
+// ListTranslations returns translations, optionally filtered by species and locale
+func (h *TranslationHandler) ListTranslations(c *fiber.Ctx) error {
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	query := db.Order("species, field, locale")
+	if species := c.Query("species"); species != "" {
+		query = query.Where("species = ?", species)
+	}
+	if locale := c.Query("locale"); locale != "" {
+		query = query.Where("locale = ?", i18n.Normalize(locale))
+	}
+
+	var translations []models.SpeciesTranslation
+	if err := query.Find(&translations).Error; err != nil {
+		return apperr.Internal("TRANSLATION_FETCH_ERROR", "Failed to fetch translations").WithCause(err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  translations,
+		"count": len(translations),
+	})
+}
+
+// UpsertTranslation creates or replaces the translation of one species field
+func (h *TranslationHandler) UpsertTranslation(c *fiber.Ctx) error {
+	var req TranslationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperr.BadRequest("INVALID_REQUEST", "Invalid request body")
+	}
+
+	req.Species = strings.ToLower(strings.TrimSpace(req.Species))
+	req.Locale = i18n.Normalize(req.Locale)
+	req.Value = strings.TrimSpace(req.Value)
+
+	if req.Species == "" {
+		return apperr.BadRequest("SPECIES_REQUIRED", "Species parameter is required")
+	}
+	if !slices.Contains(models.TranslatableFields, req.Field) {
+		return apperr.BadRequest("INVALID_FIELD", "Field '%s' cannot be translated", req.Field)
+	}
+	if req.Locale == i18n.DefaultLocale || !i18n.IsSupported(req.Locale, config.Get().SupportedLocales) {
+		return apperr.BadRequest("UNSUPPORTED_LOCALE", "Locale '%s' is not supported", req.Locale)
+	}
+	if req.Value == "" {
+		return apperr.BadRequest("VALUE_REQUIRED", "Translation value is required")
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	var origin models.SpeciesOrigin
+	if err := db.Where("species = ?", req.Species).First(&origin).Error; err != nil {
+		return apperr.NotFound("SPECIES_NOT_FOUND", "Species '%s' not found", req.Species)
+	}
+
+	translation := models.SpeciesTranslation{
+		Species: req.Species,
+		Field:   req.Field,
+		Locale:  req.Locale,
+		Value:   req.Value,
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "species"}, {Name: "field"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&translation).Error
+	if err != nil {
+		return apperr.Internal("TRANSLATION_SAVE_ERROR", "Failed to save translation").WithCause(err)
+	}
+
+	// Reload so the response carries the id of an updated row
+	db.Where("species = ? AND field = ? AND locale = ?", req.Species, req.Field, req.Locale).First(&translation)
+	recordAudit(c, db, AuditTranslationUpsert, req.Species, "%s.%s", req.Field, req.Locale)
+
+	return c.JSON(fiber.Map{
+		"data": translation,
+	})
+}
+
+// DeleteTranslation removes a translation by id
+func (h *TranslationHandler) DeleteTranslation(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return apperr.BadRequest("INVALID_ID", "Invalid translation id")
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	result := db.Delete(&models.SpeciesTranslation{}, id)
+	if result.Error != nil {
+		return apperr.Internal("TRANSLATION_DELETE_ERROR", "Failed to delete translation").WithCause(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperr.NotFound("TRANSLATION_NOT_FOUND", "Translation not found")
+	}
+	recordAudit(c, db, AuditTranslationDelete, strconv.Itoa(id), "")
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// localizeOrigins applies translations for the request locale, keeping
+// the English text when the lookup fails
+func localizeOrigins(c *fiber.Ctx, db *gorm.DB, origins []models.SpeciesOrigin) {
+	if err := i18n.LocalizeOrigins(db, middleware.GetLocale(c), origins); err != nil {
+		middleware.Log(c).Warn().Err(err).Msg("Failed to load species translations")
+	}
+}
+
+// localizeOrigin is localizeOrigins for a single origin
+func localizeOrigin(c *fiber.Ctx, db *gorm.DB, origin *models.SpeciesOrigin) {
+	if err := i18n.LocalizeOrigin(db, middleware.GetLocale(c), origin); err != nil {
+		middleware.Log(c).Warn().Err(err).Msg("Failed to load species translations")
+	}
+}
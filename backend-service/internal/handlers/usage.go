@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/middleware"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/beanspect/backend-service/internal/usage"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// UsageHandler serves metered usage
+type UsageHandler struct{}
+
+// NewUsageHandler creates a new usage handler
+func NewUsageHandler() *UsageHandler {
+	return &UsageHandler{}
+}
+
+// UsageData is the usage of the caller over a month
+type UsageData struct {
+	Client    string              `json:"client"`
+	Month     string              `json:"month"`
+	Quota     int                 `json:"quota"` // 0 for unlimited
+	Used      int                 `json:"used"`
+	Remaining *int                `json:"remaining"`
+	Days      []models.UsageDaily `json:"days"`
+}
+
+// ReportRow is one client of the admin usage report
+type ReportRow struct {
+	*usage.ClientUsage
+	Kind  string `json:"kind"` // key, user or ip
+	Name  string `json:"name"`
+	Quota int    `json:"quota"`
+}
+
+// GetUsage returns the metered calls of the caller in the month query
+// parameter (YYYY-MM), the current month by default
+func (h *UsageHandler) GetUsage(c *fiber.Ctx) error {
+	month, err := usage.ParseMonth(c.Query("month"))
+	if err != nil {
+		return apperr.BadRequest("INVALID_USAGE_MONTH", "month must be formatted as %s", "YYYY-MM")
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	client := middleware.ClientKey(c)
+	days, err := usage.Days(db, client, month)
+	if err != nil {
+		return apperr.Internal("USAGE_FETCH_ERROR", "Failed to fetch usage").WithCause(err)
+	}
+
+	data := UsageData{
+		Client: client,
+		Month:  usage.FormatMonth(month),
+		Quota:  middleware.ClientQuota(c),
+		Days:   days,
+	}
+	for _, d := range days {
+		data.Used += d.Billable()
+	}
+	if data.Quota > 0 {
+		remaining := max(data.Quota-data.Used, 0)
+		data.Remaining = &remaining
+	}
+
+	return c.JSON(fiber.Map{
+		"data": data,
+	})
+}
+
+// GetUsageReport returns the usage of every client in the month query
+// parameter, as JSON or, with format=csv, as a CSV download
+func (h *UsageHandler) GetUsageReport(c *fiber.Ctx) error {
+	month, err := usage.ParseMonth(c.Query("month"))
+	if err != nil {
+		return apperr.BadRequest("INVALID_USAGE_MONTH", "month must be formatted as %s", "YYYY-MM")
+	}
+	format := c.Query("format", "json")
+	if format != "json" && format != "csv" {
+		return apperr.BadRequest("UNSUPPORTED_FORMAT", "Unsupported export format '%s', expected one of: %s", format, "json, csv")
+	}
+
+	db := database.Get()
+	if db == nil {
+		return errDBNotConnected
+	}
+
+	report, err := usage.Report(db, month)
+	if err != nil {
+		return apperr.Internal("USAGE_FETCH_ERROR", "Failed to fetch usage").WithCause(err)
+	}
+	rows, err := reportRows(db, report)
+	if err != nil {
+		return apperr.Internal("USAGE_FETCH_ERROR", "Failed to fetch usage").WithCause(err)
+	}
+
+	if format == "json" {
+		return c.JSON(fiber.Map{
+			"month": usage.FormatMonth(month),
+			"data":  rows,
+			"count": len(rows),
+		})
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"client", "kind", "name", "quota", models.EndpointPredict, models.EndpointAnalyze, "billable", "calls", "errors"})
+	for _, r := range rows {
+		w.Write([]string{
+			r.Client,
+			r.Kind,
+			r.Name,
+			strconv.Itoa(r.Quota),
+			strconv.Itoa(r.Endpoints[models.EndpointPredict]),
+			strconv.Itoa(r.Endpoints[models.EndpointAnalyze]),
+			strconv.Itoa(r.Billable),
+			strconv.Itoa(r.Calls),
+			strconv.Itoa(r.Errors),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return apperr.Internal("USAGE_FETCH_ERROR", "Failed to fetch usage").WithCause(err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Attachment(fmt.Sprintf("beanspect-usage-%s.csv", usage.FormatMonth(month)))
+	return c.Send(buf.Bytes())
+}
+
+// reportRows names the clients of a report and attaches their quotas
+func reportRows(db *gorm.DB, report []*usage.ClientUsage) ([]ReportRow, error) {
+	var keyIDs, userIDs []uint64
+	for _, u := range report {
+		kind, raw := usage.ParseClient(u.Client)
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch kind {
+		case "key":
+			keyIDs = append(keyIDs, id)
+		case "user":
+			userIDs = append(userIDs, id)
+		}
+	}
+
+	keys := make(map[string]models.APIKey)
+	if len(keyIDs) > 0 {
+		var records []models.APIKey
+		if err := db.Where("id IN ?", keyIDs).Find(&records).Error; err != nil {
+			return nil, err
+		}
+		for _, k := range records {
+			keys[strconv.FormatUint(uint64(k.ID), 10)] = k
+		}
+	}
+	users := make(map[string]models.User)
+	if len(userIDs) > 0 {
+		var records []models.User
+		if err := db.Where("id IN ?", userIDs).Find(&records).Error; err != nil {
+			return nil, err
+		}
+		for _, u := range records {
+			users[strconv.FormatUint(uint64(u.ID), 10)] = u
+		}
+	}
+
+	defaultQuota := config.Get().UsageDefaultMonthlyQuota
+	rows := make([]ReportRow, len(report))
+	for i, u := range report {
+		kind, id := usage.ParseClient(u.Client)
+		row := ReportRow{ClientUsage: u, Kind: kind, Quota: defaultQuota}
+		switch kind {
+		case "key":
+			if k, ok := keys[id]; ok {
+				row.Name = k.Name
+				if k.MonthlyQuota != nil {
+					row.Quota = *k.MonthlyQuota
+				}
+			}
+		case "user":
+			if user, ok := users[id]; ok {
+				row.Name = user.Email
+				if user.MonthlyQuota != nil {
+					row.Quota = *user.MonthlyQuota
+				}
+			}
+		case "ip":
+			row.Name = id
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
@@ -0,0 +1,73 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is the locale stored in species_origins and used as fallback
+const DefaultLocale = "en"
+
+// Normalize lowercases a language tag and reduces it to its primary
+// subtag, so "id-ID" and "ID" both become "id"
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if base, _, ok := strings.Cut(tag, "-"); ok {
+		tag = base
+	}
+	if base, _, ok := strings.Cut(tag, "_"); ok {
+		tag = base
+	}
+	return tag
+}
+
+// IsSupported reports whether locale is one of the supported locales
+func IsSupported(locale string, supported []string) bool {
+	for _, s := range supported {
+		if Normalize(s) == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// Negotiate picks the best supported locale for an explicit lang value
+// and an Accept-Language header, in that order of precedence.
+// It returns DefaultLocale when nothing matches.
+func Negotiate(lang, acceptLanguage string, supported []string) string {
+	if l := Normalize(lang); l != "" && IsSupported(l, supported) {
+		return l
+	}
+
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag: Normalize(tag), q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	for _, c := range candidates {
+		if IsSupported(c.tag, supported) {
+			return c.tag
+		}
+	}
+	return DefaultLocale
+}
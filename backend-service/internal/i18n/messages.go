@@ -0,0 +1,126 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+)
+
+// messages holds localized error message formats keyed by locale and
+// error code. English messages live next to the handlers that raise them
+// and are passed in as the fallback.
+var messages = map[string]map[string]string{
+	"id": {
+		"FILE_REQUIRED":            "File gambar wajib diunggah",
+		"FILE_OPEN_ERROR":          "Gagal membuka file yang diunggah",
+		"FILE_READ_ERROR":          "Gagal membaca file yang diunggah",
+		"INFERENCE_ERROR":          "Layanan inferensi gagal memproses gambar",
+		"INFERENCE_REJECTED":       "Layanan inferensi menolak gambar: %s",
+		"INFERENCE_UNAVAILABLE":    "Layanan inferensi tidak tersedia",
+		"DB_NOT_CONNECTED":         "Koneksi database tidak tersedia",
+		"FETCH_ERROR":              "Gagal mengambil data asal spesies",
+		"SPECIES_REQUIRED":         "Parameter spesies wajib diisi",
+		"SPECIES_NOT_FOUND":        "Spesies '%s' tidak ditemukan",
+		"UNSUPPORTED_FORMAT":       "Format ekspor '%s' tidak didukung, gunakan salah satu dari: %s",
+		"EXPORT_ERROR":             "Gagal mengekspor data asal spesies",
+		"INVALID_IMPORT_MODE":      "Mode impor '%s' tidak didukung, gunakan salah satu dari: %s",
+		"INVALID_IMPORT_FORMAT":    "Format impor '%s' tidak didukung, gunakan salah satu dari: %s",
+		"IMPORT_PARSE_ERROR":       "File impor tidak dapat dibaca sebagai %s",
+		"IMPORT_FILE_REQUIRED":     "File GeoJSON atau CSV wajib diunggah",
+		"IMPORT_EMPTY":             "File impor tidak berisi baris data",
+		"IMPORT_INVALID_ROWS":      "%d baris gagal validasi, tidak ada data yang diimpor",
+		"IMPORT_ERROR":             "Gagal mengimpor data asal spesies",
+		"INVALID_TILE":             "Koordinat tile di luar jangkauan",
+		"LAYER_NOT_FOUND":          "Layer tile '%s' tidak ditemukan",
+		"TILE_RENDER_ERROR":        "Gagal membuat tile",
+		"BASEMAP_NOT_FOUND":        "Peta dasar '%s' tidak ditemukan",
+		"TILE_NOT_FOUND":           "Tile tidak ditemukan pada peta dasar",
+		"TILE_READ_ERROR":          "Gagal membaca tile peta dasar",
+		"INVALID_REQUEST":          "Isi permintaan tidak valid",
+		"INVALID_ID":               "ID terjemahan tidak valid",
+		"INVALID_FIELD":            "Field '%s' tidak dapat diterjemahkan",
+		"UNSUPPORTED_LOCALE":       "Locale '%s' tidak didukung",
+		"VALUE_REQUIRED":           "Nilai terjemahan wajib diisi",
+		"TRANSLATION_NOT_FOUND":    "Terjemahan tidak ditemukan",
+		"TRANSLATION_SAVE_ERROR":   "Gagal menyimpan terjemahan",
+		"TRANSLATION_DELETE_ERROR": "Gagal menghapus terjemahan",
+		"TRANSLATION_FETCH_ERROR":  "Gagal mengambil data terjemahan",
+		"FLAVOR_NOT_FOUND":         "Tag rasa '%s' tidak ditemukan",
+		"FLAVOR_FETCH_ERROR":       "Gagal mengambil roda rasa",
+		"INVALID_COMPARISON":       "Bandingkan antara %d dan %d spesies yang berbeda",
+		"INVALID_TOP_K":            "top_k harus berupa bilangan bulat positif",
+		"INVALID_THRESHOLD":        "threshold harus berupa angka antara 0 dan 1",
+		"INVALID_LOCATION":         "Lokasi pemindaian tidak valid: %s",
+		"INVALID_BBOX":             "bbox tidak valid: %s",
+		"INVALID_ZOOM":             "zoom harus antara 0 dan %d",
+		"HEATMAP_ERROR":            "Gagal membuat peta panas pemindaian",
+		"INVALID_COORDINATES":      "%s harus berupa angka antara %d dan %d",
+		"GEOCODER_NOT_LOADED":      "Geocoding balik tidak tersedia",
+		"LOCATION_NOT_FOUND":       "Tidak ada negara atau wilayah di %.5f, %.5f",
+		"PRODUCTION_FILE_REQUIRED": "File CSV volume produksi wajib diunggah",
+		"PRODUCTION_IMPORT_ERROR":  "Gagal mengimpor volume produksi",
+		"BOUNDARIES_NOT_LOADED":    "Batas wilayah negara tidak tersedia",
+		"COUNTRIES_FETCH_ERROR":    "Gagal membuat layer budidaya per negara",
+		"INVALID_RANGE":            "Filter rentang tidak valid: %s",
+		"INVALID_SITE":             "Lokasi tanam tidak valid: %s",
+		"ELEVATION_UNAVAILABLE":    "Elevasi tidak dapat ditentukan",
+		"SUITABILITY_ERROR":        "Gagal menilai kesesuaian tanam",
+		"INVALID_MONTH":            "month harus berupa angka antara %d dan %d",
+		"CALENDAR_FETCH_ERROR":     "Gagal mengambil kalender panen",
+		"CALENDAR_EXPORT_ERROR":    "Gagal mengekspor kalender panen",
+		"AUTH_REQUIRED":            "Token akses atau API key wajib disertakan",
+		"API_KEY_INVALID":          "API key tidak valid: %s",
+		"AUTH_ERROR":               "Gagal mengautentikasi permintaan",
+		"INSUFFICIENT_SCOPE":       "Kredensial tidak memiliki scope '%s'",
+		"USER_REQUIRED":            "Rute ini memerlukan pengguna yang sudah masuk",
+		"TOKEN_INVALID":            "Token akses tidak valid atau kedaluwarsa",
+		"USER_DISABLED":            "Akun pengguna dinonaktifkan",
+		"INVALID_EMAIL":            "Alamat email yang valid wajib diisi",
+		"INVALID_PASSWORD":         "Kata sandi tidak valid: %s",
+		"EMAIL_TAKEN":              "Email '%s' sudah terdaftar",
+		"REGISTRATION_ERROR":       "Gagal mendaftarkan pengguna",
+		"TOKEN_ISSUE_ERROR":        "Gagal menerbitkan token",
+		"INVALID_CREDENTIALS":      "Email atau kata sandi salah",
+		"LOGIN_ERROR":              "Gagal masuk",
+		"REFRESH_TOKEN_INVALID":    "Refresh token tidak valid, kedaluwarsa, atau sudah digunakan",
+		"LOGOUT_ERROR":             "Gagal keluar",
+		"INVALID_USER_ID":          "ID pengguna tidak valid",
+		"INVALID_ROLE":             "Peran '%s' tidak valid, gunakan salah satu dari: %s",
+		"SELF_LOCKOUT":             "Anda tidak dapat menurunkan peran atau menonaktifkan akun sendiri",
+		"USER_NOT_FOUND":           "Pengguna tidak ditemukan",
+		"USER_FETCH_ERROR":         "Gagal mengambil data pengguna",
+		"USER_UPDATE_ERROR":        "Gagal memperbarui pengguna",
+		"AUDIT_FETCH_ERROR":        "Gagal mengambil log audit",
+		"RATE_LIMITED":             "Batas permintaan terlampaui, coba lagi dalam %d detik",
+		"QUOTA_EXCEEDED":           "Kuota bulanan sebanyak %d panggilan telah habis, kuota direset pada %s",
+		"USAGE_FETCH_ERROR":        "Gagal mengambil data penggunaan",
+		"INVALID_USAGE_MONTH":      "month harus berformat %s",
+		"DOCS_ERROR":               "Gagal menyusun dokumentasi API",
+		"ROUTE_NOT_FOUND":          "Tidak dapat %s %s",
+		"INTERNAL_ERROR":           "Terjadi kesalahan internal pada server",
+	},
+}
+
+// Message returns the localized message for code, formatted with args.
+// The English fallback format is used when no translation exists.
+func Message(locale, code, fallback string, args ...interface{}) string {
+	format := fallback
+	if catalog, ok := messages[locale]; ok {
+		if localized, ok := catalog[code]; ok {
+			format = localized
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Codes returns the error codes translated for locale, sorted
+func Codes(locale string) []string {
+	codes := make([]string, 0, len(messages[locale]))
+	for code := range messages[locale] {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
@@ -0,0 +1,55 @@
+package i18n
+
+import (
+	"github.com/beanspect/backend-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// LocalizeOrigins replaces the translatable fields of origins in place with
+// their translations for locale. Fields without a translation keep the
+// English value from species_origins.
+func LocalizeOrigins(db *gorm.DB, locale string, origins []models.SpeciesOrigin) error {
+	if locale == DefaultLocale || len(origins) == 0 {
+		return nil
+	}
+
+	species := make([]string, len(origins))
+	for i, o := range origins {
+		species[i] = o.Species
+	}
+
+	var translations []models.SpeciesTranslation
+	err := db.Where("locale = ? AND species IN ?", locale, species).Find(&translations).Error
+	if err != nil {
+		return err
+	}
+
+	byKey := make(map[[2]string]string, len(translations))
+	for _, t := range translations {
+		byKey[[2]string{t.Species, t.Field}] = t.Value
+	}
+
+	for i := range origins {
+		o := &origins[i]
+		if v, ok := byKey[[2]string{o.Species, models.FieldCommonName}]; ok {
+			o.CommonName = v
+		}
+		if v, ok := byKey[[2]string{o.Species, models.FieldDescription}]; ok {
+			o.Description = v
+		}
+		if v, ok := byKey[[2]string{o.Species, models.FieldTasteProfile}]; ok {
+			o.TasteProfile = v
+		}
+	}
+	return nil
+}
+
+// LocalizeOrigin is LocalizeOrigins for a single origin
+func LocalizeOrigin(db *gorm.DB, locale string, origin *models.SpeciesOrigin) error {
+	origins := []models.SpeciesOrigin{*origin}
+	if err := LocalizeOrigins(db, locale, origins); err != nil {
+		return err
+	}
+	*origin = origins[0]
+	return nil
+}
@@ -0,0 +1,100 @@
+// Package ical writes iCalendar (RFC 5545) feeds
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxLineOctets is the longest content line before folding
+const maxLineOctets = 75
+
+// Event is an all-day event, optionally recurring
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time // first day, inclusive
+	End         time.Time // day after the last day
+	RRule       string    // e.g. "FREQ=YEARLY"
+	Stamp       time.Time
+}
+
+// Calendar is a named collection of events
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+// Write encodes the calendar to w
+func (cal Calendar) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeLine(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", cal.ProdID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		line("X-WR-CALNAME", escape(cal.Name))
+	}
+
+	for _, e := range cal.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("DTSTAMP", e.Stamp.UTC().Format("20060102T150405Z"))
+		line("DTSTART;VALUE=DATE", e.Start.Format("20060102"))
+		line("DTEND;VALUE=DATE", e.End.Format("20060102"))
+		if e.RRule != "" {
+			line("RRULE", e.RRule)
+		}
+		line("SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escape(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION", escape(e.Location))
+		}
+		line("TRANSP", "TRANSPARENT")
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// escape escapes TEXT property values
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writeLine writes a content line, folding it at 75 octets without
+// splitting UTF-8 sequences
+func writeLine(w *bufio.Writer, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
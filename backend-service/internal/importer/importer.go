@@ -0,0 +1,292 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+
+	"github.com/beanspect/backend-service/internal/geocode"
+	"github.com/beanspect/backend-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// Mode controls how imported rows are written
+type Mode string
+
+// Supported import modes
+const (
+	// ModeDryRun validates and plans the import, then rolls it back
+	ModeDryRun Mode = "dry-run"
+	// ModeInsert only creates new species and fails on existing ones
+	ModeInsert Mode = "insert"
+	// ModeUpsert creates new species and updates the columns the file
+	// provides of existing ones
+	ModeUpsert Mode = "upsert"
+)
+
+// Modes returns the supported import mode names
+func Modes() []string {
+	return []string{string(ModeDryRun), string(ModeInsert), string(ModeUpsert)}
+}
+
+// ParseMode validates a mode string
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case ModeDryRun, ModeInsert, ModeUpsert:
+		return Mode(s), nil
+	default:
+		return "", fmt.Errorf("unsupported import mode %q, expected dry-run, insert or upsert", s)
+	}
+}
+
+// ErrInvalidRows is returned when one or more rows fail validation
+var ErrInvalidRows = errors.New("import contains invalid rows")
+
+// RowError reports the errors of a single row
+type RowError struct {
+	Row     int          `json:"row"`
+	Species string       `json:"species,omitempty"`
+	Errors  []FieldError `json:"errors"`
+}
+
+// Result summarises an import run
+type Result struct {
+	Mode      Mode       `json:"mode"`
+	Total     int        `json:"total"`
+	Inserted  int        `json:"inserted"`
+	Updated   int        `json:"updated"`
+	Committed bool       `json:"committed"`
+	Errors    []RowError `json:"errors"`
+}
+
+var speciesPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Validate checks every row against the SpeciesOrigin constraints and
+// for duplicate species within the file. Country and region are filled
+// from the coordinates when blank and checked against them otherwise.
+func Validate(rows []Row) []RowError {
+	seen := map[string]int{}
+	var errs []RowError
+
+	for i := range rows {
+		row := &rows[i]
+		o := row.Origin
+
+		switch {
+		case o.Species == "":
+			row.addError("species", "species is required")
+		case !speciesPattern.MatchString(o.Species):
+			row.addError("species", "species may only contain lowercase letters, digits, '-' and '_'")
+		}
+		if first, dup := seen[o.Species]; dup && o.Species != "" {
+			row.addError("species", "duplicate species, first seen in row %d", first)
+		} else {
+			seen[o.Species] = row.Index
+		}
+
+		validCoords := true
+		if !finite(o.Latitude) || o.Latitude < -90 || o.Latitude > 90 {
+			row.addError("latitude", "latitude must be between -90 and 90")
+			validCoords = false
+		}
+		if !finite(o.Longitude) || o.Longitude < -180 || o.Longitude > 180 {
+			row.addError("longitude", "longitude must be between -180 and 180")
+			validCoords = false
+		}
+		if validCoords {
+			checkLocation(row)
+		}
+		if row.Origin.Country == "" {
+			row.addError("country", "country is required")
+		}
+
+		if o.TempMinC != nil && !finite(*o.TempMinC) {
+			row.addError("temp_min_c", "temp_min_c must be a finite number")
+		}
+		if o.TempMaxC != nil && !finite(*o.TempMaxC) {
+			row.addError("temp_max_c", "temp_max_c must be a finite number")
+		}
+		if o.TempMinC != nil && o.TempMaxC != nil && *o.TempMinC > *o.TempMaxC {
+			row.addError("temp_min_c", "temp_min_c must not exceed temp_max_c")
+		}
+		if o.RainfallMinMm != nil && o.RainfallMaxMm != nil && *o.RainfallMinMm > *o.RainfallMaxMm {
+			row.addError("rainfall_min_mm", "rainfall_min_mm must not exceed rainfall_max_mm")
+		}
+		if o.LatitudeBelt != nil && (!finite(*o.LatitudeBelt) || *o.LatitudeBelt < 0 || *o.LatitudeBelt > 90) {
+			row.addError("latitude_belt", "latitude_belt must be between 0 and 90")
+		}
+
+		checkLength(row, "species", o.Species, 50)
+		checkLength(row, "common_name", o.CommonName, 100)
+		checkLength(row, "scientific_name", o.ScientificName, 150)
+		checkLength(row, "country", o.Country, 100)
+		checkLength(row, "region", o.Region, 100)
+		checkLength(row, "caffeine_level", o.CaffeineLevel, 50)
+		checkLength(row, "altitude", o.Altitude, 50)
+		checkLength(row, "image_url", o.ImageURL, 500)
+
+		if o.ImageURL != "" {
+			if u, err := url.ParseRequestURI(o.ImageURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				row.addError("image_url", "image_url must be an absolute http(s) URL")
+			}
+		}
+
+		if len(row.Errors) > 0 {
+			errs = append(errs, RowError{Row: row.Index, Species: o.Species, Errors: row.Errors})
+		}
+	}
+	return errs
+}
+
+// checkLocation fills an empty country and region from the coordinates
+// and rejects a country or known region that the coordinates fall outside.
+// Points snapped to a nearby boundary are only used to fill blanks.
+func checkLocation(row *Row) {
+	g := geocode.Get()
+	if g == nil {
+		return
+	}
+	o := &row.Origin
+	place := g.Reverse(o.Latitude, o.Longitude)
+	if place == nil {
+		return
+	}
+
+	if o.Country == "" {
+		o.Country = place.CountryName()
+		row.provide("country")
+	} else if !place.Approximate && !place.MatchesCountry(o.Country) {
+		row.addError("country", "coordinates fall in %s, not %s", place.CountryName(), o.Country)
+		return
+	}
+
+	if o.Region == "" {
+		o.Region = place.RegionName()
+		row.provide("region")
+	} else if !place.Approximate && place.Region != nil && !place.MatchesRegion(o.Region) && g.KnownRegion(place, o.Region) {
+		row.addError("region", "coordinates fall in %s, not %s", place.RegionName(), o.Region)
+	}
+}
+
+// finite reports whether v is neither NaN nor infinite
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+func checkLength(row *Row, field, value string, max int) {
+	if len([]rune(value)) > max {
+		row.addError(field, "%s must be at most %d characters", field, max)
+	}
+}
+
+// updateColumns returns the columns an upsert of row writes over current:
+// those the file provides and the ranges derived from them. A soft-deleted
+// species is restored. The range strings the file leaves out are copied
+// from current, so the derived ranges are parsed from both.
+func updateColumns(row *Row, origin *models.SpeciesOrigin, current models.SpeciesOrigin) []string {
+	columns := append([]string{}, row.Columns...)
+
+	if row.provides("caffeine_level") || row.provides("altitude") {
+		if !row.provides("caffeine_level") {
+			origin.CaffeineLevel = current.CaffeineLevel
+		}
+		if !row.provides("altitude") {
+			origin.Altitude = current.Altitude
+		}
+		columns = append(columns, "caffeine_min_pct", "caffeine_max_pct", "altitude_min_m", "altitude_max_m", "range_parse_error")
+	}
+
+	if current.DeletedAt.Valid {
+		origin.DeletedAt = gorm.DeletedAt{}
+		columns = append(columns, "deleted_at")
+	}
+	return columns
+}
+
+// Run validates rows and writes them in a single transaction. Any row
+// error rolls back the whole import; dry runs are always rolled back.
+func Run(db *gorm.DB, rows []Row, mode Mode) (*Result, error) {
+	result := &Result{Mode: mode, Total: len(rows), Errors: []RowError{}}
+
+	if errs := Validate(rows); len(errs) > 0 {
+		result.Errors = errs
+		return result, ErrInvalidRows
+	}
+
+	errRollback := errors.New("rollback")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		names := make([]string, len(rows))
+		for i, row := range rows {
+			names[i] = row.Origin.Species
+		}
+
+		var found []models.SpeciesOrigin
+		if err := tx.Unscoped().Where("species IN ?", names).Find(&found).Error; err != nil {
+			return err
+		}
+		existing := make(map[string]models.SpeciesOrigin, len(found))
+		for _, o := range found {
+			existing[o.Species] = o
+		}
+
+		if mode == ModeInsert {
+			for i := range rows {
+				row := &rows[i]
+				if _, ok := existing[row.Origin.Species]; ok {
+					row.addError("species", "species %q already exists", row.Origin.Species)
+					result.Errors = append(result.Errors, RowError{Row: row.Index, Species: row.Origin.Species, Errors: row.Errors})
+				}
+			}
+			if len(result.Errors) > 0 {
+				return ErrInvalidRows
+			}
+		}
+
+		for i := range rows {
+			row := &rows[i]
+			origin := row.Origin
+
+			if current, ok := existing[origin.Species]; ok {
+				origin.ID = current.ID
+				if err := tx.Unscoped().Select(updateColumns(row, &origin, current)).Updates(&origin).Error; err != nil {
+					row.addError("", "failed to update: %v", err)
+				} else {
+					result.Updated++
+				}
+			} else {
+				if err := tx.Create(&origin).Error; err != nil {
+					row.addError("", "failed to insert: %v", err)
+				} else {
+					result.Inserted++
+				}
+			}
+
+			if len(row.Errors) > 0 {
+				result.Errors = append(result.Errors, RowError{Row: row.Index, Species: origin.Species, Errors: row.Errors})
+				// Postgres aborts the transaction on a failed statement, stop here
+				return ErrInvalidRows
+			}
+		}
+
+		if mode == ModeDryRun {
+			return errRollback
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		result.Committed = true
+		return result, nil
+	case errors.Is(err, errRollback):
+		return result, nil
+	default:
+		if !errors.Is(err, ErrInvalidRows) {
+			return result, err
+		}
+		result.Inserted, result.Updated = 0, 0
+		return result, ErrInvalidRows
+	}
+}
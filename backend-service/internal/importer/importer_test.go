@@ -0,0 +1,141 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/beanspect/backend-service/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	_ "modernc.org/sqlite"
+)
+
+// TestUpsertPartialCSV upserts a CSV with a few columns over a fully
+// populated species and checks that only those columns change
+func TestUpsertPartialCSV(t *testing.T) {
+	db := openTestDB(t)
+	tempMin, tempMax, belt := 15.0, 24.0, 25.0
+	rainMin, rainMax := 1200, 2200
+	full := models.SpeciesOrigin{
+		Species:        "arabica",
+		CommonName:     "Arabica",
+		ScientificName: "Coffea arabica",
+		Country:        "Ethiopia",
+		Region:         "Oromia",
+		Latitude:       9.145,
+		Longitude:      40.4897,
+		Description:    "The original description",
+		TasteProfile:   "Sweet, fruity",
+		CaffeineLevel:  "Low to Medium (1.2-1.5%)",
+		Altitude:       "1000-2000m",
+		TempMinC:       &tempMin,
+		TempMaxC:       &tempMax,
+		RainfallMinMm:  &rainMin,
+		RainfallMaxMm:  &rainMax,
+		LatitudeBelt:   &belt,
+		ImageURL:       "https://example.com/arabica.jpg",
+	}
+	if err := db.Create(&full).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	csv := "species,country,lat,lng,description,altitude\n" +
+		"arabica,Ethiopia,9.2,40.5,A new description,1200-2200m\n"
+	rows, err := ParseCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Run(db, rows, ModeUpsert)
+	if err != nil {
+		t.Fatalf("upsert failed: %v (%+v)", err, result.Errors)
+	}
+	if result.Updated != 1 || result.Inserted != 0 {
+		t.Fatalf("upsert updated %d and inserted %d, want 1 update", result.Updated, result.Inserted)
+	}
+
+	var got models.SpeciesOrigin
+	if err := db.First(&got, full.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Provided by the file
+	if got.Latitude != 9.2 || got.Longitude != 40.5 {
+		t.Errorf("coordinates = %v, %v, want 9.2, 40.5", got.Latitude, got.Longitude)
+	}
+	if got.Description != "A new description" {
+		t.Errorf("description = %q, want the imported one", got.Description)
+	}
+	if got.Altitude != "1200-2200m" || got.AltitudeMinM == nil || *got.AltitudeMinM != 1200 {
+		t.Errorf("altitude = %q from %v, want 1200-2200m parsed", got.Altitude, got.AltitudeMinM)
+	}
+
+	// Left out of the file
+	if got.CommonName != full.CommonName || got.ScientificName != full.ScientificName || got.Region != full.Region {
+		t.Errorf("names and region = %q, %q, %q, want them kept", got.CommonName, got.ScientificName, got.Region)
+	}
+	if got.TasteProfile != full.TasteProfile || got.ImageURL != full.ImageURL {
+		t.Errorf("taste profile and image = %q, %q, want them kept", got.TasteProfile, got.ImageURL)
+	}
+	if got.CaffeineLevel != full.CaffeineLevel || got.CaffeineMinPct == nil || *got.CaffeineMinPct != 1.2 {
+		t.Errorf("caffeine = %q from %v, want it kept", got.CaffeineLevel, got.CaffeineMinPct)
+	}
+	if got.TempMinC == nil || got.TempMaxC == nil || got.RainfallMinMm == nil || got.RainfallMaxMm == nil || got.LatitudeBelt == nil {
+		t.Errorf("climate ranges were cleared: %+v", got)
+	}
+	if got.RangeParseError != "" {
+		t.Errorf("range parse error = %q, want none", got.RangeParseError)
+	}
+}
+
+// TestUpsertRestoresDeleted checks that upserting a soft-deleted species
+// brings it back
+func TestUpsertRestoresDeleted(t *testing.T) {
+	db := openTestDB(t)
+	origin := models.SpeciesOrigin{Species: "liberica", CommonName: "Liberica", Country: "Liberia", Latitude: 6.428, Longitude: -9.429}
+	if err := db.Create(&origin).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&origin).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ParseCSV(strings.NewReader("species,country,lat,lng\nliberica,Liberia,6.4,-9.4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Run(db, rows, ModeUpsert); err != nil {
+		t.Fatal(err)
+	}
+
+	var got models.SpeciesOrigin
+	if err := db.First(&got, origin.ID).Error; err != nil {
+		t.Fatalf("species was not restored: %v", err)
+	}
+	if got.CommonName != "Liberica" {
+		t.Errorf("common name = %q, want it kept", got.CommonName)
+	}
+}
+
+// openTestDB returns an in-memory SQLite database with the origins table
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dialector := sqlite.Dialector{DriverName: "sqlite", DSN: "file::memory:"}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to :memory: opens a database of its own
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&models.SpeciesOrigin{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
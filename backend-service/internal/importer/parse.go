@@ -0,0 +1,329 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/beanspect/backend-service/internal/models"
+)
+
+// Row is one parsed record of an import file
+type Row struct {
+	// Index is the 1-based feature number or CSV data row number
+	Index  int
+	Origin models.SpeciesOrigin
+	// Columns lists the origin columns the record provides, which are
+	// the only ones an upsert overwrites
+	Columns []string
+	Errors  []FieldError
+}
+
+// FieldError describes a problem with a single field of a row
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (r *Row) addError(field, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// provide records that the row sets column
+func (r *Row) provide(column string) {
+	if !r.provides(column) {
+		r.Columns = append(r.Columns, column)
+	}
+}
+
+func (r *Row) provides(column string) bool {
+	return slices.Contains(r.Columns, column)
+}
+
+// Format identifies the import file format
+type Format string
+
+// Supported import formats
+const (
+	FormatGeoJSON Format = "geojson"
+	FormatCSV     Format = "csv"
+)
+
+// Formats returns the supported import format names
+func Formats() []string {
+	return []string{string(FormatGeoJSON), string(FormatCSV)}
+}
+
+// ParseError reports a file that cannot be read in its format at all, as
+// opposed to one whose rows fail validation. Reason is the parser's own
+// description of the problem.
+type ParseError struct {
+	Format Format `json:"format"`
+	Reason string `json:"reason"`
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Format, e.Reason)
+}
+
+// DetectFormat guesses the format from a filename, falling back to
+// sniffing the first non-whitespace byte of the content
+func DetectFormat(filename string, content []byte) Format {
+	lower := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(lower, ".csv"):
+		return FormatCSV
+	case strings.HasSuffix(lower, ".geojson"), strings.HasSuffix(lower, ".json"):
+		return FormatGeoJSON
+	}
+
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return FormatGeoJSON
+	}
+	return FormatCSV
+}
+
+// Parse reads every row from content in the given format
+func Parse(format Format, content []byte) ([]Row, error) {
+	switch format {
+	case FormatGeoJSON:
+		return ParseGeoJSON(bytes.NewReader(content))
+	case FormatCSV:
+		return ParseCSV(bytes.NewReader(content))
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+}
+
+// fieldAliases maps accepted column or property names to model fields
+var fieldAliases = map[string]string{
+	"species":         "species",
+	"common_name":     "common_name",
+	"scientific_name": "scientific_name",
+	"country":         "country",
+	"region":          "region",
+	"latitude":        "latitude",
+	"lat":             "latitude",
+	"y":               "latitude",
+	"longitude":       "longitude",
+	"lng":             "longitude",
+	"lon":             "longitude",
+	"long":            "longitude",
+	"x":               "longitude",
+	"description":     "description",
+	"taste_profile":   "taste_profile",
+	"caffeine_level":  "caffeine_level",
+	"altitude":        "altitude",
+	"image_url":       "image_url",
+	"temp_min_c":      "temp_min_c",
+	"temp_max_c":      "temp_max_c",
+	"rainfall_min_mm": "rainfall_min_mm",
+	"rainfall_max_mm": "rainfall_max_mm",
+	"latitude_belt":   "latitude_belt",
+}
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type     string `json:"type"`
+	Geometry *struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// ParseGeoJSON reads a FeatureCollection of Point features
+func ParseGeoJSON(r io.Reader) ([]Row, error) {
+	var fc geoJSONCollection
+	if err := json.NewDecoder(r).Decode(&fc); err != nil {
+		return nil, &ParseError{Format: FormatGeoJSON, Reason: err.Error()}
+	}
+	if fc.Type != "FeatureCollection" {
+		return nil, &ParseError{Format: FormatGeoJSON, Reason: "root must be a FeatureCollection"}
+	}
+
+	rows := make([]Row, len(fc.Features))
+	for i, f := range fc.Features {
+		row := Row{Index: i + 1}
+
+		values := make(map[string]string, len(f.Properties))
+		for k, v := range f.Properties {
+			if v == nil {
+				continue
+			}
+			values[k] = fmt.Sprint(v)
+		}
+
+		if f.Geometry != nil {
+			if f.Geometry.Type != "Point" || len(f.Geometry.Coordinates) < 2 {
+				row.addError("geometry", "geometry must be a Point with [longitude, latitude]")
+			} else {
+				values["longitude"] = strconv.FormatFloat(f.Geometry.Coordinates[0], 'f', -1, 64)
+				values["latitude"] = strconv.FormatFloat(f.Geometry.Coordinates[1], 'f', -1, 64)
+			}
+		}
+
+		applyValues(&row, values)
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// ParseCSV reads a CSV file whose header names the SpeciesOrigin fields.
+// Latitude and longitude columns may also be named lat/lng/lon/x/y.
+func ParseCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, &ParseError{Format: FormatCSV, Reason: "invalid header: " + err.Error()}
+	}
+	for i, h := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+	}
+
+	var rows []Row
+	for index := 1; ; index++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		row := Row{Index: index}
+		if err != nil {
+			row.addError("", "malformed CSV row: %v", err)
+			rows = append(rows, row)
+			continue
+		}
+
+		values := make(map[string]string, len(header))
+		for i, h := range header {
+			if i < len(record) {
+				values[h] = record[i]
+			}
+		}
+
+		applyValues(&row, values)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// applyValues maps raw string values onto the row's origin
+func applyValues(row *Row, values map[string]string) {
+	o := &row.Origin
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		raw := values[key]
+		field, ok := fieldAliases[strings.ToLower(key)]
+		if !ok {
+			continue
+		}
+		value := strings.TrimSpace(raw)
+		row.provide(field)
+
+		switch field {
+		case "species":
+			o.Species = strings.ToLower(value)
+		case "common_name":
+			o.CommonName = value
+		case "scientific_name":
+			o.ScientificName = value
+		case "country":
+			o.Country = value
+		case "region":
+			o.Region = value
+		case "latitude", "longitude":
+			v, err := parseNumber(value)
+			if err != nil {
+				row.addError(field, "%s must be a number", field)
+				continue
+			}
+			if field == "latitude" {
+				o.Latitude = v
+			} else {
+				o.Longitude = v
+			}
+		case "description":
+			o.Description = value
+		case "taste_profile":
+			o.TasteProfile = value
+		case "caffeine_level":
+			o.CaffeineLevel = value
+		case "altitude":
+			o.Altitude = value
+		case "image_url":
+			o.ImageURL = value
+		case "temp_min_c", "temp_max_c", "latitude_belt":
+			if value == "" {
+				continue
+			}
+			v, err := parseNumber(value)
+			if err != nil {
+				row.addError(field, "%s must be a number", field)
+				continue
+			}
+			switch field {
+			case "temp_min_c":
+				o.TempMinC = &v
+			case "temp_max_c":
+				o.TempMaxC = &v
+			default:
+				o.LatitudeBelt = &v
+			}
+		case "rainfall_min_mm", "rainfall_max_mm":
+			if value == "" {
+				continue
+			}
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				row.addError(field, "%s must be an integer", field)
+				continue
+			}
+			if field == "rainfall_min_mm" {
+				o.RainfallMinMm = &v
+			} else {
+				o.RainfallMaxMm = &v
+			}
+		}
+	}
+
+	if !row.provides("latitude") {
+		row.addError("latitude", "latitude is required")
+	}
+	if !row.provides("longitude") {
+		row.addError("longitude", "longitude is required")
+	}
+}
+
+// parseNumber parses a finite number. strconv also accepts NaN and
+// infinities, which compare false against every bound and cannot be
+// encoded as JSON.
+func parseNumber(value string) (float64, error) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("%q is not a finite number", value)
+	}
+	return v, nil
+}
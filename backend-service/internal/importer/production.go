@@ -0,0 +1,242 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/beanspect/backend-service/internal/geocode"
+	"github.com/beanspect/backend-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProductionRow is one parsed record of a production volume CSV
+type ProductionRow struct {
+	Index      int
+	Production models.CountryProduction
+	Errors     []FieldError
+}
+
+func (r *ProductionRow) addError(field, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// productionAliases maps accepted CSV column names to production fields
+var productionAliases = map[string]string{
+	"country":       "country",
+	"country_code":  "country",
+	"iso_a3":        "country",
+	"species":       "species",
+	"volume":        "volume",
+	"volume_tonnes": "volume",
+	"tonnes":        "volume",
+	"year":          "year",
+	"source":        "source",
+}
+
+// ParseProductionCSV reads a CSV with country, species and volume_tonnes
+// columns, all required, and optional year and source columns. Country
+// may be a name or ISO code.
+func ParseProductionCSV(r io.Reader) ([]ProductionRow, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, &ParseError{Format: FormatCSV, Reason: "invalid header: " + err.Error()}
+	}
+	for i, h := range header {
+		header[i] = productionAliases[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))]
+	}
+
+	var rows []ProductionRow
+	for index := 1; ; index++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		row := ProductionRow{Index: index}
+		if err != nil {
+			row.addError("", "malformed CSV row: %v", err)
+			rows = append(rows, row)
+			continue
+		}
+
+		p := &row.Production
+		hasVolume := false
+		for i, field := range header {
+			if i >= len(record) || field == "" {
+				continue
+			}
+			value := strings.TrimSpace(record[i])
+			switch field {
+			case "country":
+				p.Country = value
+			case "species":
+				p.Species = strings.ToLower(value)
+			case "volume":
+				hasVolume = true
+				v, err := parseNumber(value)
+				if err != nil {
+					row.addError("volume_tonnes", "volume_tonnes must be a number")
+					continue
+				}
+				p.VolumeTonnes = v
+			case "year":
+				if value == "" {
+					continue
+				}
+				v, err := strconv.Atoi(value)
+				if err != nil {
+					row.addError("year", "year must be an integer")
+					continue
+				}
+				p.Year = v
+			case "source":
+				p.Source = value
+			}
+		}
+		if !hasVolume {
+			row.addError("volume_tonnes", "volume_tonnes is required")
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ValidateProduction checks every row and resolves its country to the
+// canonical name and ISO alpha-3 code of the boundary dataset
+func ValidateProduction(rows []ProductionRow) []RowError {
+	g := geocode.Get()
+	seen := map[string]int{}
+	var errs []RowError
+
+	for i := range rows {
+		row := &rows[i]
+		p := &row.Production
+
+		switch {
+		case p.Species == "":
+			row.addError("species", "species is required")
+		case !speciesPattern.MatchString(p.Species):
+			row.addError("species", "species may only contain lowercase letters, digits, '-' and '_'")
+		}
+
+		switch {
+		case p.Country == "":
+			row.addError("country", "country is required")
+		case g == nil:
+			row.addError("country", "country boundaries are not loaded")
+		default:
+			if c := g.LookupCountry(p.Country); c == nil || c.ISOA3 == "" {
+				row.addError("country", "unknown country %q", p.Country)
+			} else {
+				p.Country, p.CountryCode = c.Name, c.ISOA3
+			}
+		}
+
+		if !finite(p.VolumeTonnes) {
+			row.addError("volume_tonnes", "volume_tonnes must be a finite number")
+		} else if p.VolumeTonnes < 0 {
+			row.addError("volume_tonnes", "volume_tonnes must not be negative")
+		}
+		if p.Year != 0 && (p.Year < 1900 || p.Year > 2100) {
+			row.addError("year", "year must be between 1900 and 2100")
+		}
+		checkProductionLength(row, "source", p.Source, 255)
+
+		key := fmt.Sprintf("%s/%s/%d", p.CountryCode, p.Species, p.Year)
+		if first, dup := seen[key]; dup && p.CountryCode != "" {
+			row.addError("", "duplicate country, species and year, first seen in row %d", first)
+		} else {
+			seen[key] = row.Index
+		}
+
+		if len(row.Errors) > 0 {
+			errs = append(errs, RowError{Row: row.Index, Species: p.Species, Errors: row.Errors})
+		}
+	}
+	return errs
+}
+
+func checkProductionLength(row *ProductionRow, field, value string, max int) {
+	if len([]rune(value)) > max {
+		row.addError(field, "%s must be at most %d characters", field, max)
+	}
+}
+
+// RunProduction validates rows and writes them in a single transaction,
+// keyed by country, species and year. Dry runs are always rolled back.
+func RunProduction(db *gorm.DB, rows []ProductionRow, mode Mode) (*Result, error) {
+	result := &Result{Mode: mode, Total: len(rows), Errors: []RowError{}}
+
+	if errs := ValidateProduction(rows); len(errs) > 0 {
+		result.Errors = errs
+		return result, ErrInvalidRows
+	}
+
+	errRollback := errors.New("rollback")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var found []models.CountryProduction
+		if err := tx.Find(&found).Error; err != nil {
+			return err
+		}
+		existing := make(map[string]bool, len(found))
+		for _, p := range found {
+			existing[fmt.Sprintf("%s/%s/%d", p.CountryCode, p.Species, p.Year)] = true
+		}
+
+		for i := range rows {
+			row := &rows[i]
+			p := row.Production
+			exists := existing[fmt.Sprintf("%s/%s/%d", p.CountryCode, p.Species, p.Year)]
+			if exists && mode == ModeInsert {
+				row.addError("", "production for %s, %s, %d already exists", p.Country, p.Species, p.Year)
+				result.Errors = append(result.Errors, RowError{Row: row.Index, Species: p.Species, Errors: row.Errors})
+				continue
+			}
+
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "country_code"}, {Name: "species"}, {Name: "year"}},
+				DoUpdates: clause.AssignmentColumns([]string{"country", "volume_tonnes", "source", "updated_at"}),
+			}).Create(&p).Error
+			if err != nil {
+				row.addError("", "failed to save: %v", err)
+				result.Errors = append(result.Errors, RowError{Row: row.Index, Species: p.Species, Errors: row.Errors})
+				// Postgres aborts the transaction on a failed statement, stop here
+				return ErrInvalidRows
+			}
+			if exists {
+				result.Updated++
+			} else {
+				result.Inserted++
+			}
+		}
+		if len(result.Errors) > 0 {
+			return ErrInvalidRows
+		}
+
+		if mode == ModeDryRun {
+			return errRollback
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		result.Committed = true
+		return result, nil
+	case errors.Is(err, errRollback):
+		return result, nil
+	default:
+		if !errors.Is(err, ErrInvalidRows) {
+			return result, err
+		}
+		result.Inserted, result.Updated = 0, 0
+		return result, ErrInvalidRows
+	}
+}
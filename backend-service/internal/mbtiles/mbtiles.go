@@ -0,0 +1,172 @@
+package mbtiles
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	_ "modernc.org/sqlite"
+)
+
+// ErrTileNotFound is returned when the requested tile is not in the file
+var ErrTileNotFound = errors.New("tile not found")
+
+// Metadata holds the parsed contents of an MBTiles metadata table
+type Metadata struct {
+	Name        string
+	Description string
+	Attribution string
+	Version     string
+	Format      string
+	MinZoom     int
+	MaxZoom     int
+	Bounds      []float64
+	Center      []float64
+
+	// JSON is the raw "json" row, which for vector tilesets carries the
+	// vector_layers description
+	JSON string
+}
+
+// Reader provides read-only access to a single MBTiles file
+type Reader struct {
+	Path     string
+	Metadata Metadata
+	db       *sql.DB
+}
+
+// Open opens an MBTiles file read-only and loads its metadata
+func Open(path string) (*Reader, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mbtiles %s: %w", path, err)
+	}
+
+	r := &Reader{Path: path, db: db}
+	if err := r.loadMetadata(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to read mbtiles metadata %s: %w", path, err)
+	}
+
+	log.Info().
+		Str("path", path).
+		Str("name", r.Metadata.Name).
+		Str("format", r.Metadata.Format).
+		Int("minzoom", r.Metadata.MinZoom).
+		Int("maxzoom", r.Metadata.MaxZoom).
+		Msg("Opened MBTiles file")
+
+	return r, nil
+}
+
+// Close closes the underlying database
+func (r *Reader) Close() error {
+	return r.db.Close()
+}
+
+// Tile returns the raw tile data for XYZ tile z/x/y. MBTiles stores rows
+// in TMS order, so the y coordinate is flipped before the lookup.
+func (r *Reader) Tile(z, x, y int) ([]byte, error) {
+	tmsY := (1 << uint(z)) - 1 - y
+
+	var data []byte
+	err := r.db.QueryRow(
+		"SELECT tile_data FROM tiles WHERE zoom_level = ? AND tile_column = ? AND tile_row = ?",
+		z, x, tmsY,
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ContentType returns the media type of the tiles in this file
+func (r *Reader) ContentType() string {
+	switch r.Metadata.Format {
+	case "png":
+		return "image/png"
+	case "jpg", "jpeg":
+		return "image/jpeg"
+	case "webp":
+		return "image/webp"
+	case "pbf":
+		return "application/x-protobuf"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// IsVector reports whether the file holds vector tiles
+func (r *Reader) IsVector() bool {
+	return r.Metadata.Format == "pbf"
+}
+
+func (r *Reader) loadMetadata() error {
+	rows, err := r.db.Query("SELECT name, value FROM metadata")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	m := Metadata{MinZoom: 0, MaxZoom: 22}
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return err
+		}
+		switch name {
+		case "name":
+			m.Name = value
+		case "description":
+			m.Description = value
+		case "attribution":
+			m.Attribution = value
+		case "version":
+			m.Version = value
+		case "format":
+			m.Format = strings.ToLower(value)
+		case "minzoom":
+			if v, err := strconv.Atoi(value); err == nil {
+				m.MinZoom = v
+			}
+		case "maxzoom":
+			if v, err := strconv.Atoi(value); err == nil {
+				m.MaxZoom = v
+			}
+		case "bounds":
+			m.Bounds = parseFloats(value)
+		case "center":
+			m.Center = parseFloats(value)
+		case "json":
+			m.JSON = value
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if m.Format == "" {
+		m.Format = "png"
+	}
+	r.Metadata = m
+	return nil
+}
+
+func parseFloats(s string) []float64 {
+	parts := strings.Split(s, ",")
+	out := make([]float64, 0, len(parts))
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil
+		}
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,138 @@
+// Package metrics collects the Prometheus metrics of the service
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "beanspect"
+
+// Registry holds every metric the service exports
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	inferenceDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "inference_request_duration_seconds",
+		Help:      "Latency of prediction requests to the inference service by outcome.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 15, 30},
+	}, []string{"outcome"})
+
+	inferenceErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "inference_errors_total",
+		Help:      "Failed prediction requests by upstream error code.",
+	}, []string{"code"})
+
+	predictions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "predictions_total",
+		Help:      "Predictions by predicted species.",
+	}, []string{"species"})
+
+	predictionConfidence = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "prediction_confidence",
+		Help:      "Confidence of predictions by predicted species.",
+		Buckets:   []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1},
+	}, []string{"species"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		inferenceDuration,
+		inferenceErrors,
+		predictions,
+		predictionConfidence,
+	)
+}
+
+// ObserveRequest records a served HTTP request. route is the route
+// pattern rather than the path, keeping the number of series bounded.
+func ObserveRequest(method, route string, status int, latency time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(latency.Seconds())
+}
+
+// ObserveInference records a prediction request to the inference service.
+// code is empty on success and the upstream error code otherwise.
+func ObserveInference(latency time.Duration, code string) {
+	outcome := "success"
+	if code != "" {
+		outcome = "error"
+		inferenceErrors.WithLabelValues(code).Inc()
+	}
+	inferenceDuration.WithLabelValues(outcome).Observe(latency.Seconds())
+}
+
+// ObservePrediction records the species and confidence of a prediction
+func ObservePrediction(species string, confidence float64) {
+	predictions.WithLabelValues(species).Inc()
+	predictionConfidence.WithLabelValues(species).Observe(confidence)
+}
+
+// RegisterDB exports the connection pool stats of db
+func RegisterDB(db *sql.DB) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// HealthCheck reports whether a dependency is up
+type HealthCheck func(ctx context.Context) bool
+
+// RegisterHealth exports the state of dependencies as beanspect_up, one
+// series per component. Checks run on every scrape, each bounded by
+// timeout.
+func RegisterHealth(timeout time.Duration, checks map[string]HealthCheck) error {
+	return Registry.Register(&healthCollector{timeout: timeout, checks: checks})
+}
+
+var upDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "up"),
+	"Whether a dependency is reachable (1) or not (0).",
+	[]string{"component"}, nil,
+)
+
+type healthCollector struct {
+	timeout time.Duration
+	checks  map[string]HealthCheck
+}
+
+func (h *healthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- upDesc
+}
+
+func (h *healthCollector) Collect(ch chan<- prometheus.Metric) {
+	for component, check := range h.checks {
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		up := 0.0
+		if check(ctx) {
+			up = 1
+		}
+		cancel()
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, up, component)
+	}
+}
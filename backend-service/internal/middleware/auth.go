@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/beanspect/backend-service/internal/apikey"
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/auth"
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// PrincipalKey is the fiber.Ctx locals key holding the authenticated caller
+const PrincipalKey = "principal"
+
+// HeaderAPIKey is the header API keys may be sent in instead of
+// Authorization: Bearer
+const HeaderAPIKey = "X-API-Key"
+
+// apiKeyPrefix tells API keys apart from JWT access tokens
+const apiKeyPrefix = "bsk_"
+
+// Principal is the caller of a request: a signed-in user or an API key
+type Principal struct {
+	User   *models.User
+	APIKey *models.APIKey
+}
+
+// HasScope reports whether the caller may use routes requiring scope.
+// Users are granted the scopes of their role.
+func (p *Principal) HasScope(scope string) bool {
+	switch {
+	case p.User != nil:
+		return p.User.HasScope(scope)
+	case p.APIKey != nil:
+		return p.APIKey.HasScope(scope)
+	default:
+		return false
+	}
+}
+
+// RequireScope is a middleware that rejects requests whose user role or
+// API key does not grant scope. With authentication disabled every request
+// passes, though valid credentials are still attributed.
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !config.Get().AuthEnabled {
+			if requestCredential(c) != "" {
+				authenticate(c)
+			}
+			return c.Next()
+		}
+
+		principal, authErr := authenticate(c)
+		if authErr != nil {
+			return authFailure(c, authErr)
+		}
+		if !principal.HasScope(scope) {
+			return apperr.Forbidden("INSUFFICIENT_SCOPE", "Credentials lack the '%s' scope", scope)
+		}
+		return c.Next()
+	}
+}
+
+// RequireUser is a middleware that rejects requests not made by a
+// signed-in user
+func RequireUser() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, authErr := authenticate(c)
+		if authErr != nil {
+			return authFailure(c, authErr)
+		}
+		if principal.User == nil {
+			return apperr.Forbidden("USER_REQUIRED", "This route requires a signed-in user")
+		}
+		return c.Next()
+	}
+}
+
+// GetUser returns the user who made the request, if any
+func GetUser(c *fiber.Ctx) *models.User {
+	if p, ok := c.Locals(PrincipalKey).(*Principal); ok {
+		return p.User
+	}
+	return nil
+}
+
+// GetAPIKey returns the API key that authenticated the request, if any
+func GetAPIKey(c *fiber.Ctx) *models.APIKey {
+	if p, ok := c.Locals(PrincipalKey).(*Principal); ok {
+		return p.APIKey
+	}
+	return nil
+}
+
+// ClientKey identifies the caller for rate limits and metering: key:<id>
+// for API keys, user:<id> for users and ip:<address> otherwise.
+// Credentials are resolved here when no scope check has run yet; invalid
+// ones fall back to the IP address.
+func ClientKey(c *fiber.Ctx) string {
+	if requestCredential(c) != "" {
+		if principal, authErr := authenticate(c); authErr == nil {
+			switch {
+			case principal.APIKey != nil:
+				return "key:" + strconv.FormatUint(uint64(principal.APIKey.ID), 10)
+			case principal.User != nil:
+				return "user:" + strconv.FormatUint(uint64(principal.User.ID), 10)
+			}
+		}
+	}
+	return "ip:" + c.IP()
+}
+
+// authenticate resolves the caller from the request credentials, once
+// per request
+func authenticate(c *fiber.Ctx) (*Principal, *apperr.Error) {
+	if p, ok := c.Locals(PrincipalKey).(*Principal); ok {
+		return p, nil
+	}
+
+	credential := requestCredential(c)
+	if credential == "" {
+		return nil, apperr.Unauthorized("AUTH_REQUIRED", "An access token or API key is required")
+	}
+
+	db := database.Get()
+	if db == nil {
+		return nil, apperr.Unavailable("DB_NOT_CONNECTED", "Database connection not available")
+	}
+
+	var principal *Principal
+	var authErr *apperr.Error
+	if strings.HasPrefix(credential, apiKeyPrefix) {
+		principal, authErr = authenticateAPIKey(db, credential)
+	} else {
+		principal, authErr = authenticateUser(db, credential)
+	}
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	c.Locals(PrincipalKey, principal)
+	return principal, nil
+}
+
+func authenticateAPIKey(db *gorm.DB, key string) (*Principal, *apperr.Error) {
+	record, err := apikey.Authenticate(db, key)
+	switch {
+	case errors.Is(err, apikey.ErrInvalid), errors.Is(err, apikey.ErrRevoked), errors.Is(err, apikey.ErrExpired):
+		return nil, apperr.Unauthorized("API_KEY_INVALID", "API key is invalid: %s", err.Error())
+	case err != nil:
+		return nil, apperr.Internal("AUTH_ERROR", "Failed to authenticate request").WithCause(err)
+	}
+	return &Principal{APIKey: record}, nil
+}
+
+func authenticateUser(db *gorm.DB, token string) (*Principal, *apperr.Error) {
+	claims, err := auth.ParseAccessToken(token)
+	if err != nil {
+		return nil, apperr.Unauthorized("TOKEN_INVALID", "Access token is invalid or expired")
+	}
+	id, _ := claims.UserID()
+
+	var user models.User
+	if err := db.First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperr.Unauthorized("TOKEN_INVALID", "Access token is invalid or expired")
+		}
+		return nil, apperr.Internal("AUTH_ERROR", "Failed to authenticate request").WithCause(err)
+	}
+	if user.Disabled {
+		return nil, apperr.Forbidden("USER_DISABLED", "User account is disabled")
+	}
+	return &Principal{User: &user}, nil
+}
+
+// authFailure adds the WWW-Authenticate challenge to authentication
+// failures
+func authFailure(c *fiber.Ctx, err *apperr.Error) error {
+	switch {
+	case err.Code == "AUTH_REQUIRED":
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="beanspect"`)
+	case err.Status == fiber.StatusUnauthorized:
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="beanspect", error="invalid_token"`)
+	}
+	return err
+}
+
+// requestCredential reads the access token or API key from the
+// Authorization or X-API-Key header, falling back to the api_key query
+// parameter for clients that cannot set headers, such as calendar
+// subscriptions
+func requestCredential(c *fiber.Ctx) string {
+	if header := c.Get(fiber.HeaderAuthorization); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	if key := strings.TrimSpace(c.Get(HeaderAPIKey)); key != "" {
+		return key
+	}
+	return strings.TrimSpace(c.Query("api_key"))
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Deprecated is a middleware for routes that alias their successors under
+// another prefix while clients migrate. Responses carry the Deprecation
+// (RFC 9745) and Sunset (RFC 8594) headers and link to the same path
+// under successor. A zero sunset omits the Sunset header.
+func Deprecated(prefix, successor string, deprecatedAt, sunset time.Time) fiber.Handler {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", deprecation)
+		if !sunset.IsZero() {
+			c.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		c.Append(fiber.HeaderLink, `<`+successor+strings.TrimPrefix(c.Path(), prefix)+`>; rel="successor-version"`)
+		return c.Next()
+	}
+}
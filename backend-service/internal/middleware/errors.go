@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/i18n"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorKey is the fiber.Ctx locals key holding the error a request was
+// answered with
+const ErrorKey = "error"
+
+// ErrorHandler renders the errors returned by handlers and middleware.
+// Application errors keep their status and code; anything else is an
+// internal error whose cause is only shown outside production.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	appErr, ok := apperr.As(err)
+	if !ok {
+		appErr = fromError(c, err)
+	}
+	c.Locals(ErrorKey, appErr)
+
+	body := apperr.ErrorBody{
+		Error:     true,
+		Code:      appErr.Code,
+		Message:   i18n.Message(GetLocale(c), appErr.Code, appErr.Format, appErr.Args...),
+		Details:   appErr.Details,
+		RequestID: GetRequestID(c),
+	}
+	if appErr.Cause != nil && config.Get().Env != "production" {
+		body.Cause = appErr.Cause.Error()
+	}
+
+	switch {
+	case appErr.Status >= fiber.StatusInternalServerError:
+		Log(c).Error().Err(appErr.Cause).Str("code", appErr.Code).
+			Str("method", c.Method()).Str("path", c.Path()).Msg(appErr.Message())
+	case appErr.Cause != nil:
+		Log(c).Warn().Err(appErr.Cause).Str("code", appErr.Code).
+			Str("method", c.Method()).Str("path", c.Path()).Msg(appErr.Message())
+	}
+
+	return c.Status(appErr.Status).JSON(body)
+}
+
+// GetError returns the error the request was answered with, if any
+func GetError(c *fiber.Ctx) *apperr.Error {
+	if err, ok := c.Locals(ErrorKey).(*apperr.Error); ok {
+		return err
+	}
+	return nil
+}
+
+// fromError maps an error that is not an application error: Fiber's own
+// errors, such as unknown routes, or an unexpected failure
+func fromError(c *fiber.Ctx, err error) *apperr.Error {
+	var fiberErr *fiber.Error
+	if !errors.As(err, &fiberErr) {
+		return apperr.Internal("INTERNAL_ERROR", "Internal server error").WithCause(err)
+	}
+
+	switch fiberErr.Code {
+	case fiber.StatusNotFound:
+		return apperr.NotFound("ROUTE_NOT_FOUND", "Cannot %s %s", c.Method(), c.Path())
+	case fiber.StatusInternalServerError:
+		return apperr.Internal("INTERNAL_ERROR", "Internal server error").WithCause(err)
+	}
+	code := strings.ToUpper(strings.ReplaceAll(http.StatusText(fiberErr.Code), " ", "_"))
+	if code == "" {
+		code = "HTTP_ERROR"
+	}
+	return apperr.New(fiberErr.Code, code, fiberErr.Message)
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/i18n"
+	"github.com/gofiber/fiber/v2"
+)
+
+// LocaleKey is the fiber.Ctx locals key holding the negotiated locale
+const LocaleKey = "locale"
+
+// Locale is a middleware that negotiates the response locale from the
+// lang query parameter or the Accept-Language header
+func Locale() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cfg := config.Get()
+		locale := i18n.Negotiate(c.Query("lang"), c.Get(fiber.HeaderAcceptLanguage), cfg.SupportedLocales)
+
+		c.Locals(LocaleKey, locale)
+		c.Set(fiber.HeaderContentLanguage, locale)
+		c.Vary(fiber.HeaderAcceptLanguage)
+
+		return c.Next()
+	}
+}
+
+// GetLocale returns the locale negotiated for the request
+func GetLocale(c *fiber.Ctx) string {
+	if locale, ok := c.Locals(LocaleKey).(string); ok {
+		return locale
+	}
+	return i18n.DefaultLocale
+}
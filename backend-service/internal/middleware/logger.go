@@ -4,7 +4,6 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/rs/zerolog/log"
 )
 
 // Logger is a middleware that logs incoming requests
@@ -12,14 +11,19 @@ func Logger() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		start := time.Now()
 
-		// Process request
-		err := c.Next()
+		// Process request, rendering errors here so the logged status is
+		// the one sent
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				return err
+			}
+		}
 
 		// Calculate latency
 		latency := time.Since(start)
 
 		// Log request
-		log.Info().
+		Log(c).Info().
 			Str("method", c.Method()).
 			Str("path", c.Path()).
 			Int("status", c.Response().StatusCode()).
@@ -27,6 +31,6 @@ func Logger() fiber.Handler {
 			Str("ip", c.IP()).
 			Msg("Request processed")
 
-		return err
+		return nil
 	}
 }
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/beanspect/backend-service/internal/metrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// unmatchedRoute labels requests no route matched, which would otherwise
+// be attributed to the last middleware they passed
+const unmatchedRoute = "unmatched"
+
+// Metrics is a middleware that counts requests and measures their
+// latency by route pattern and status
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		// Render errors first so the recorded status is the one sent
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				return err
+			}
+		}
+
+		// Labels outlive the request, so they must not share its buffers
+		metrics.ObserveRequest(utils.CopyString(c.Method()), routePattern(c), c.Response().StatusCode(), time.Since(start))
+		return nil
+	}
+}
+
+// routePattern returns a copy of the pattern of the route that served the
+// request
+func routePattern(c *fiber.Ctx) string {
+	if err := GetError(c); err != nil && err.Code == "ROUTE_NOT_FOUND" {
+		return unmatchedRoute
+	}
+	return utils.CopyString(c.Route().Path)
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/ratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimit is a middleware that throttles callers with a token bucket
+// named budget. Callers are keyed by API key, then user, then IP address.
+// The store failing lets requests through rather than taking the API down.
+func RateLimit(store ratelimit.Store, budget string, limit ratelimit.Limit) fiber.Handler {
+	policy := fmt.Sprintf("%d;w=60;burst=%d", limit.PerMinute, limit.Burst)
+
+	return func(c *fiber.Ctx) error {
+		if !limit.Enabled() {
+			return c.Next()
+		}
+
+		key := budget + ":" + ClientKey(c)
+		result, err := store.Take(c.UserContext(), key, limit, time.Now())
+		if err != nil {
+			Log(c).Error().Err(err).Str("budget", budget).Msg("Failed to check rate limit")
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Policy", policy)
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			return apperr.TooManyRequests("RATE_LIMITED", "Rate limit exceeded, retry in %d second(s)", retryAfter)
+		}
+		return c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"github.com/beanspect/backend-service/internal/requestid"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// RequestIDKey is the fiber.Ctx locals key holding the request ID
+const RequestIDKey = "requestid"
+
+// RequestID is a middleware that takes the request ID from the
+// X-Request-ID header, generating one when absent or malformed, and
+// echoes it in the response. The ID and a logger tagged with it are added
+// to the request's user context, which services receive.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// The ID outlives the request in logs and traces, so it must not
+		// share the request's buffers
+		id := utils.CopyString(c.Get(requestid.Header))
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		c.Locals(RequestIDKey, id)
+		c.Set(requestid.Header, id)
+
+		logger := log.With().Str("request_id", id).Logger()
+		ctx := requestid.NewContext(c.UserContext(), id)
+		c.SetUserContext(logger.WithContext(ctx))
+
+		return c.Next()
+	}
+}
+
+// GetRequestID returns the ID of the request
+func GetRequestID(c *fiber.Ctx) string {
+	if id, ok := c.Locals(RequestIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// Log returns the logger of the request, which tags every event with the
+// request ID
+func Log(c *fiber.Ctx) *zerolog.Logger {
+	return zerolog.Ctx(c.UserContext())
+}
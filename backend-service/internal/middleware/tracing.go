@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/beanspect/backend-service/internal/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing is a middleware that wraps each request in a server span,
+// continuing the trace of the caller when it sends W3C trace context. The
+// span is added to the request's user context, which services receive.
+func Tracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Spans are exported after the request, so strings kept in them
+		// must not share its buffers
+		method := utils.CopyString(c.Method())
+		propagator := otel.GetTextMapPropagator()
+		carrier := propagation.HeaderCarrier{}
+		for _, field := range propagator.Fields() {
+			if value := c.Get(field); value != "" {
+				carrier.Set(field, utils.CopyString(value))
+			}
+		}
+
+		ctx := propagator.Extract(c.UserContext(), carrier)
+		ctx, span := tracing.Tracer().Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(method),
+				semconv.URLPath(utils.CopyString(c.Path())),
+				semconv.ClientAddress(utils.CopyString(c.IP())),
+			),
+		)
+		defer span.End()
+		if id := GetRequestID(c); id != "" {
+			span.SetAttributes(attribute.StringSlice("http.request.header.x-request-id", []string{id}))
+		}
+		c.SetUserContext(ctx)
+
+		// Render errors first so the recorded status is the one sent
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				return err
+			}
+		}
+
+		status := c.Response().StatusCode()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if route := routePattern(c); route != unmatchedRoute {
+			span.SetName(method + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		if status >= fiber.StatusInternalServerError {
+			if err := GetError(c); err != nil {
+				span.RecordError(err)
+			}
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return nil
+	}
+}
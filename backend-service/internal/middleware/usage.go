@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/apperr"
+	"github.com/beanspect/backend-service/internal/config"
+	"github.com/beanspect/backend-service/internal/database"
+	"github.com/beanspect/backend-service/internal/usage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Quota headers set on metered responses
+const (
+	HeaderQuotaLimit     = "X-Quota-Limit"
+	HeaderQuotaRemaining = "X-Quota-Remaining"
+	HeaderQuotaReset     = "X-Quota-Reset"
+)
+
+// Meter is a middleware that counts calls of a metered endpoint per client
+// and rejects them once the client's monthly quota is spent. A call is
+// reserved against the quota before it is served and refunded if it
+// fails. Metering is skipped while the database is unavailable.
+func Meter(endpoint string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		db := database.Get()
+		if db == nil {
+			return c.Next()
+		}
+
+		client := ClientKey(c)
+		quota := ClientQuota(c)
+		now := time.Now().UTC()
+		month := usage.MonthStart(now)
+		reset := month.AddDate(0, 1, 0)
+
+		used, reserved, err := usage.Reserve(db, client, month, quota)
+		if err != nil {
+			Log(c).Error().Err(err).Str("client", client).Msg("Failed to reserve usage quota")
+			quota = 0
+		}
+
+		if quota > 0 {
+			c.Set(HeaderQuotaLimit, strconv.Itoa(quota))
+			c.Set(HeaderQuotaReset, reset.Format(time.RFC3339))
+			if !reserved {
+				c.Set(HeaderQuotaRemaining, "0")
+				return apperr.TooManyRequests("QUOTA_EXCEEDED", "Monthly quota of %d calls is used up, it resets on %s", quota, reset.Format("2006-01-02"))
+			}
+		}
+
+		err = c.Next()
+
+		failed := err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest
+		if recErr := usage.Record(db, client, endpoint, now, failed); recErr != nil {
+			Log(c).Error().Err(recErr).Str("client", client).Msg("Failed to record usage")
+		}
+		if failed && reserved {
+			if refundErr := usage.Refund(db, client, month); refundErr != nil {
+				Log(c).Error().Err(refundErr).Str("client", client).Msg("Failed to refund usage quota")
+			}
+			used--
+		}
+		if quota > 0 {
+			c.Set(HeaderQuotaRemaining, strconv.Itoa(max(quota-used, 0)))
+		}
+		return err
+	}
+}
+
+// ClientQuota returns the monthly inference quota of the caller, 0 for
+// unlimited. A quota set on the API key or user overrides the default.
+func ClientQuota(c *fiber.Ctx) int {
+	if p, ok := c.Locals(PrincipalKey).(*Principal); ok {
+		switch {
+		case p.APIKey != nil && p.APIKey.MonthlyQuota != nil:
+			return *p.APIKey.MonthlyQuota
+		case p.User != nil && p.User.MonthlyQuota != nil:
+			return *p.User.MonthlyQuota
+		}
+	}
+	return config.Get().UsageDefaultMonthlyQuota
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// Location sources of a geotagged analysis
+const (
+	LocationSourceClient = "client"
+	LocationSourceEXIF   = "exif"
+)
+
+// Analysis records the result of an image analysis and, when known,
+// where the bean was scanned
+type Analysis struct {
+	ID         uint    `gorm:"primaryKey" json:"id"`
+	Species    string  `gorm:"size:50;index;not null" json:"species"`
+	Confidence float64 `json:"confidence"`
+
+	// Scan Location
+	Latitude       *float64 `json:"latitude"`
+	Longitude      *float64 `json:"longitude"`
+	AccuracyM      *float64 `json:"accuracy_m"`
+	LocationSource string   `gorm:"size:20" json:"location_source,omitempty"`
+	Geohash        string   `gorm:"size:12;index" json:"geohash,omitempty"`
+	Country        string   `gorm:"size:100;index" json:"country,omitempty"`
+	Region         string   `gorm:"size:100" json:"region,omitempty"`
+
+	// Attribution
+	UserID   *uint `gorm:"index" json:"user_id,omitempty"`
+	APIKeyID *uint `gorm:"index" json:"api_key_id,omitempty"`
+
+	// Metadata
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Analysis) TableName() string {
+	return "analyses"
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// API key scopes
+const (
+	ScopeAnalyze      = "analyze"
+	ScopeOriginsRead  = "origins:read"
+	ScopeOriginsWrite = "origins:write"
+	ScopeMetrics      = "metrics"
+	ScopeAdmin        = "admin"
+)
+
+// Scopes lists every API key scope
+var Scopes = []string{ScopeAnalyze, ScopeOriginsRead, ScopeOriginsWrite, ScopeMetrics, ScopeAdmin}
+
+// APIKey is a client credential. Only a SHA-256 hash of the key is stored;
+// the key itself is shown once when it is created.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	Prefix     string     `gorm:"size:16;index;not null" json:"prefix"` // first characters of the key, for identification
+	KeyHash    string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Scopes     string     `gorm:"size:255;not null" json:"scopes"` // comma-separated
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `gorm:"index" json:"revoked_at"`
+
+	// Monthly inference calls allowed, overriding the default quota.
+	// Zero means unlimited.
+	MonthlyQuota *int `json:"monthly_quota"`
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList returns the scopes granted to the key
+func (k APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return nil
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// HasScope reports whether the key grants scope. The admin scope grants
+// every other scope.
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the key is neither revoked nor expired at t
+func (k APIKey) Active(t time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || t.Before(*k.ExpiresAt)
+}
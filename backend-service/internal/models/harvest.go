@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// Harvest crops
+const (
+	CropMain = "main"
+	CropFly  = "fly"
+)
+
+// HarvestWindow is the yearly harvest season of a species in a growing
+// region. Months run 1-12 and a window may wrap past December.
+type HarvestWindow struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	Species    string `gorm:"size:50;not null;uniqueIndex:idx_harvest_species_region_crop" json:"species"`
+	Country    string `gorm:"size:100;not null;uniqueIndex:idx_harvest_species_region_crop" json:"country"`
+	Region     string `gorm:"size:100;uniqueIndex:idx_harvest_species_region_crop" json:"region"`
+	Crop       string `gorm:"size:10;not null;uniqueIndex:idx_harvest_species_region_crop" json:"crop"` // main, fly
+	StartMonth int    `gorm:"not null" json:"start_month"`
+	EndMonth   int    `gorm:"not null" json:"end_month"`
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (HarvestWindow) TableName() string {
+	return "harvest_windows"
+}
+
+// Covers reports whether month falls within the window
+func (w HarvestWindow) Covers(month int) bool {
+	if w.StartMonth <= w.EndMonth {
+		return month >= w.StartMonth && month <= w.EndMonth
+	}
+	return month >= w.StartMonth || month <= w.EndMonth
+}
+
+// Months returns the months of the window in harvest order
+func (w HarvestWindow) Months() []int {
+	var months []int
+	for m := w.StartMonth; ; m = m%12 + 1 {
+		months = append(months, m)
+		if m == w.EndMonth || len(months) == 12 {
+			return months
+		}
+	}
+}
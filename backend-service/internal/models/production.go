@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// CountryProduction is the coffee production volume of a species in a
+// country for a harvest year, as imported from a CSV
+type CountryProduction struct {
+	ID           uint    `gorm:"primaryKey" json:"id"`
+	CountryCode  string  `gorm:"size:3;not null;uniqueIndex:idx_production_country_species_year" json:"country_code"` // ISO 3166-1 alpha-3
+	Country      string  `gorm:"size:100;not null" json:"country"`
+	Species      string  `gorm:"size:50;not null;uniqueIndex:idx_production_country_species_year" json:"species"`
+	Year         int     `gorm:"not null;uniqueIndex:idx_production_country_species_year" json:"year"` // 0 when unknown
+	VolumeTonnes float64 `gorm:"type:decimal(14,2)" json:"volume_tonnes"`
+	Source       string  `gorm:"size:255" json:"source,omitempty"`
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (CountryProduction) TableName() string {
+	return "country_productions"
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// RateLimitBucket is the state of a token bucket shared between backend
+// instances
+type RateLimitBucket struct {
+	Key       string    `gorm:"primaryKey;size:255"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"index;not null"`
+}
+
+// TableName specifies the table name for GORM
+func (RateLimitBucket) TableName() string {
+	return "rate_limit_buckets"
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+// FlavorTag is a node of the SCA coffee taster's flavor wheel
+type FlavorTag struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Slug     string `gorm:"uniqueIndex;size:50;not null" json:"slug"`
+	Name     string `gorm:"size:100;not null" json:"name"`
+	ParentID *uint  `gorm:"index" json:"parent_id"`
+	Level    int    `gorm:"not null" json:"level"` // 1 = inner ring of the wheel
+}
+
+// TableName specifies the table name for GORM
+func (FlavorTag) TableName() string {
+	return "flavor_tags"
+}
+
+// SensoryProfile is the structured taste model of a species. Scores are
+// on a 0-10 scale; the free-text SpeciesOrigin.TasteProfile stays the summary.
+type SensoryProfile struct {
+	ID      uint   `gorm:"primaryKey" json:"id"`
+	Species string `gorm:"uniqueIndex;size:50;not null" json:"species"`
+
+	// Numeric Attributes
+	Acidity    float64 `gorm:"type:decimal(3,1)" json:"acidity"`
+	Body       float64 `gorm:"type:decimal(3,1)" json:"body"`
+	Sweetness  float64 `gorm:"type:decimal(3,1)" json:"sweetness"`
+	Bitterness float64 `gorm:"type:decimal(3,1)" json:"bitterness"`
+
+	// Flavor Wheel
+	FlavorTags []FlavorTag `gorm:"many2many:sensory_profile_flavor_tags" json:"flavor_tags"`
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (SensoryProfile) TableName() string {
+	return "sensory_profiles"
+}
@@ -1,8 +1,10 @@
 package models
 
 import (
+	"strings"
 	"time"
 
+	"github.com/beanspect/backend-service/internal/units"
 	"gorm.io/gorm"
 )
 
@@ -25,6 +27,20 @@ type SpeciesOrigin struct {
 	CaffeineLevel string `gorm:"size:50" json:"caffeine_level"` // low, medium, high
 	Altitude      string `gorm:"size:50" json:"altitude"`       // e.g., "1000-2000m"
 
+	// Numeric ranges parsed from CaffeineLevel and Altitude
+	CaffeineMinPct  *float64 `gorm:"type:decimal(4,2);index" json:"caffeine_min_pct"`
+	CaffeineMaxPct  *float64 `gorm:"type:decimal(4,2);index" json:"caffeine_max_pct"`
+	AltitudeMinM    *int     `gorm:"index" json:"altitude_min_m"`
+	AltitudeMaxM    *int     `gorm:"index" json:"altitude_max_m"`
+	RangeParseError string   `gorm:"size:255" json:"range_parse_error,omitempty"`
+
+	// Growing Climate
+	TempMinC      *float64 `gorm:"type:decimal(4,1)" json:"temp_min_c"`
+	TempMaxC      *float64 `gorm:"type:decimal(4,1)" json:"temp_max_c"`
+	RainfallMinMm *int     `json:"rainfall_min_mm"`
+	RainfallMaxMm *int     `json:"rainfall_max_mm"`
+	LatitudeBelt  *float64 `gorm:"type:decimal(4,1)" json:"latitude_belt"` // furthest latitude north or south it is grown at
+
 	// Media
 	ImageURL string `gorm:"size:500" json:"image_url"`
 
@@ -38,3 +54,39 @@ type SpeciesOrigin struct {
 func (SpeciesOrigin) TableName() string {
 	return "species_origins"
 }
+
+// ParseRanges fills the numeric caffeine and altitude columns from their
+// display strings. Fields that cannot be parsed are left empty and noted
+// in RangeParseError.
+func (o *SpeciesOrigin) ParseRanges() {
+	var problems []string
+
+	o.CaffeineMinPct, o.CaffeineMaxPct = nil, nil
+	if o.CaffeineLevel != "" {
+		if min, max, err := units.ParseCaffeine(o.CaffeineLevel); err == nil {
+			o.CaffeineMinPct, o.CaffeineMaxPct = &min, &max
+		} else {
+			problems = append(problems, "caffeine_level: "+err.Error())
+		}
+	}
+
+	o.AltitudeMinM, o.AltitudeMaxM = nil, nil
+	if o.Altitude != "" {
+		if min, max, err := units.ParseAltitude(o.Altitude); err == nil {
+			o.AltitudeMinM, o.AltitudeMaxM = &min, &max
+		} else {
+			problems = append(problems, "altitude: "+err.Error())
+		}
+	}
+
+	o.RangeParseError = strings.Join(problems, "; ")
+	if len(o.RangeParseError) > 255 {
+		o.RangeParseError = o.RangeParseError[:255]
+	}
+}
+
+// BeforeSave keeps the numeric ranges in sync with the display strings
+func (o *SpeciesOrigin) BeforeSave(tx *gorm.DB) error {
+	o.ParseRanges()
+	return nil
+}
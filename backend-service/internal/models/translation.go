@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// Translatable SpeciesOrigin fields
+const (
+	FieldCommonName   = "common_name"
+	FieldDescription  = "description"
+	FieldTasteProfile = "taste_profile"
+)
+
+// TranslatableFields lists the SpeciesOrigin fields that can be localized
+var TranslatableFields = []string{FieldCommonName, FieldDescription, FieldTasteProfile}
+
+// SpeciesTranslation holds a localized value of a species text field
+type SpeciesTranslation struct {
+	ID      uint   `gorm:"primaryKey" json:"id"`
+	Species string `gorm:"uniqueIndex:idx_species_field_locale;size:50;not null" json:"species"`
+	Field   string `gorm:"uniqueIndex:idx_species_field_locale;size:50;not null" json:"field"`
+	Locale  string `gorm:"uniqueIndex:idx_species_field_locale;size:10;not null" json:"locale"`
+	Value   string `gorm:"type:text;not null" json:"value"`
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (SpeciesTranslation) TableName() string {
+	return "species_translations"
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// Metered endpoints
+const (
+	EndpointPredict = "predict"
+	EndpointAnalyze = "analyze"
+)
+
+// UsageDaily counts the metered calls of a client on one UTC day.
+// Successful calls count towards the monthly quota; failed ones are kept
+// apart so clients are not charged for server errors.
+type UsageDaily struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	ClientKey string    `gorm:"size:100;not null;uniqueIndex:idx_usage_client_day_endpoint" json:"client"` // key:<id>, user:<id> or ip:<address>
+	Day       time.Time `gorm:"type:date;not null;uniqueIndex:idx_usage_client_day_endpoint;index" json:"day"`
+	Endpoint  string    `gorm:"size:20;not null;uniqueIndex:idx_usage_client_day_endpoint" json:"endpoint"`
+	Calls     int       `gorm:"not null;default:0" json:"calls"`
+	Errors    int       `gorm:"not null;default:0" json:"errors"`
+}
+
+// TableName specifies the table name for GORM
+func (UsageDaily) TableName() string {
+	return "usage_daily"
+}
+
+// Billable is the number of calls counted towards the quota
+func (u UsageDaily) Billable() int {
+	return u.Calls - u.Errors
+}
+
+// UsageMonthly counts the billable calls of a client in one UTC month.
+// Calls are reserved against it before they are served, so it also
+// includes calls in flight; UsageDaily keeps the breakdown for reports.
+type UsageMonthly struct {
+	ClientKey string    `gorm:"primaryKey;size:100" json:"client"`
+	Month     time.Time `gorm:"primaryKey;type:date" json:"month"`
+	Billable  int       `gorm:"not null;default:0" json:"billable"`
+}
+
+// TableName specifies the table name for GORM
+func (UsageMonthly) TableName() string {
+	return "usage_monthly"
+}
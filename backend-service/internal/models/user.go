@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+)
+
+// User roles
+const (
+	RoleGrader   = "grader"
+	RoleReviewer = "reviewer"
+	RoleAdmin    = "admin"
+)
+
+// Roles lists every user role
+var Roles = []string{RoleGrader, RoleReviewer, RoleAdmin}
+
+// roleScopes are the API scopes each role is granted
+var roleScopes = map[string][]string{
+	RoleGrader:   {ScopeAnalyze, ScopeOriginsRead},
+	RoleReviewer: {ScopeAnalyze, ScopeOriginsRead, ScopeOriginsWrite},
+	RoleAdmin:    {ScopeAdmin},
+}
+
+// User is a member of the cooperative who signs in with a password
+type User struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Email        string     `gorm:"size:255;uniqueIndex;not null" json:"email"`
+	Name         string     `gorm:"size:100" json:"name"`
+	PasswordHash string     `gorm:"size:100;not null" json:"-"`
+	Role         string     `gorm:"size:20;not null;default:grader" json:"role"`
+	Disabled     bool       `gorm:"not null;default:false" json:"disabled"`
+	LastLoginAt  *time.Time `json:"last_login_at"`
+
+	// Monthly inference calls allowed, overriding the default quota.
+	// Zero means unlimited.
+	MonthlyQuota *int `json:"monthly_quota"`
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (User) TableName() string {
+	return "users"
+}
+
+// HasScope reports whether the user's role grants scope
+func (u User) HasScope(scope string) bool {
+	for _, s := range roleScopes[u.Role] {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// RefreshToken is an opaque, single-use token exchanged for a new access
+// token. Tokens rotated from the same login share a family so a reused
+// token can revoke the whole chain.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Family    string     `gorm:"size:32;index;not null" json:"family"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	UserAgent string     `gorm:"size:255" json:"user_agent,omitempty"`
+	IP        string     `gorm:"size:45" json:"ip,omitempty"`
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// AuditLog records an administrative change and who made it
+type AuditLog struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	UserID   *uint  `gorm:"index" json:"user_id"`
+	APIKeyID *uint  `gorm:"index" json:"api_key_id"`
+	Action   string `gorm:"size:50;index;not null" json:"action"`
+	Target   string `gorm:"size:255" json:"target"`
+	Detail   string `gorm:"type:text" json:"detail,omitempty"`
+
+	// Metadata
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
@@ -0,0 +1,305 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Route annotates a registered route with what reflection cannot tell
+type Route struct {
+	Tag         string
+	Summary     string
+	Description string
+	Security    []string // security schemes accepting the route; empty for public routes
+	Query       []Param
+	Form        []Param                // multipart/form-data fields
+	Body        interface{}            // JSON request body
+	RawBody     []string               // content types accepted as a raw body
+	Response    interface{}            // JSON value returned under "data"
+	List        bool                   // the response is {"data": [...], "count": n}
+	Fields      map[string]interface{} // top-level fields returned next to "data"
+	Raw         bool                   // Response is returned as is, without the data envelope
+	ContentType string                 // content type of a non-JSON success response
+	Status      int                    // success status, 200 by default
+	Headers     []string               // names of success response headers
+	Errors      []string               // error codes the route can return
+}
+
+// Param is a query or form parameter
+type Param struct {
+	Name        string
+	Type        string // string, integer, number, boolean or file
+	Description string
+	Required    bool
+	Enum        []string
+}
+
+// Builder assembles a document from annotated routes
+type Builder struct {
+	doc         *Document
+	schemas     *Schemas
+	errorSchema *Schema
+	errorStatus map[string]int
+	headers     map[string]*Header
+}
+
+// NewBuilder starts a document. errorType is the body of error responses
+// and errorStatus maps every error code to its HTTP status.
+func NewBuilder(info Info, errorType interface{}, errorStatus map[string]int) *Builder {
+	schemas := NewSchemas()
+	b := &Builder{
+		doc: &Document{
+			OpenAPI: "3.0.3",
+			Info:    info,
+			Paths:   make(map[string]*PathItem),
+		},
+		schemas:     schemas,
+		errorSchema: schemas.For(errorType),
+		errorStatus: errorStatus,
+		headers:     make(map[string]*Header),
+	}
+
+	codes := make([]string, 0, len(errorStatus))
+	for code := range errorStatus {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	if name := strings.TrimPrefix(b.errorSchema.Ref, "#/components/schemas/"); name != "" {
+		if code, ok := schemas.Components()[name].Properties["code"]; ok {
+			code.Enum = codes
+		}
+	}
+	return b
+}
+
+// Schemas returns the schema registry of the document
+func (b *Builder) Schemas() *Schemas {
+	return b.schemas
+}
+
+// AddTag describes a tag
+func (b *Builder) AddTag(name, description string) {
+	b.doc.Tags = append(b.doc.Tags, Tag{Name: name, Description: description})
+}
+
+// AddServer adds a base URL
+func (b *Builder) AddServer(url, description string) {
+	b.doc.Servers = append(b.doc.Servers, Server{URL: url, Description: description})
+}
+
+// AddSecurityScheme registers a way to authenticate
+func (b *Builder) AddSecurityScheme(name string, scheme *SecurityScheme) {
+	if b.doc.Components.SecuritySchemes == nil {
+		b.doc.Components.SecuritySchemes = make(map[string]*SecurityScheme)
+	}
+	b.doc.Components.SecuritySchemes[name] = scheme
+}
+
+// AddHeader describes a response header routes may list
+func (b *Builder) AddHeader(name, typ, description string) {
+	b.headers[name] = &Header{Description: description, Schema: &Schema{Type: typ}}
+}
+
+var (
+	// fiberParam matches the :name parameters of a Fiber path
+	fiberParam = regexp.MustCompile(`:(\w+)`)
+	// nonAlphanumeric splits paths into operation id words
+	nonAlphanumeric = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// Add documents one route. path uses Fiber's :param syntax.
+func (b *Builder) Add(method, path string, r Route) error {
+	oasPath := fiberParam.ReplaceAllString(path, "{$1}")
+	item, ok := b.doc.Paths[oasPath]
+	if !ok {
+		item = &PathItem{}
+	}
+	slot := item.operation(method)
+	if slot == nil {
+		return fmt.Errorf("unsupported method %s", method)
+	}
+
+	op := &Operation{
+		Summary:     r.Summary,
+		Description: r.Description,
+		OperationID: operationID(method, path),
+		Responses:   make(map[string]*Response),
+	}
+	if r.Tag != "" {
+		op.Tags = []string{r.Tag}
+	}
+
+	for _, m := range fiberParam.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: pathParamType(m[1])}})
+	}
+	for _, q := range r.Query {
+		op.Parameters = append(op.Parameters, Parameter{Name: q.Name, In: "query", Description: q.Description, Required: q.Required, Schema: paramSchema(q)})
+	}
+
+	op.RequestBody = b.requestBody(r)
+	for _, scheme := range r.Security {
+		op.Security = append(op.Security, map[string][]string{scheme: {}})
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	op.Responses[strconv.Itoa(status)] = b.successResponse(r, status)
+
+	if err := b.addErrors(op, r.Errors); err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+
+	*slot = op
+	b.doc.Paths[oasPath] = item
+	return nil
+}
+
+// Document returns the assembled document
+func (b *Builder) Document() *Document {
+	b.doc.Components.Schemas = b.schemas.Components()
+	return b.doc
+}
+
+func (b *Builder) requestBody(r Route) *RequestBody {
+	content := make(map[string]*MediaType)
+	if r.Body != nil {
+		content["application/json"] = &MediaType{Schema: b.schemas.For(r.Body)}
+	}
+	if len(r.Form) > 0 {
+		form := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for _, f := range r.Form {
+			field := paramSchema(f)
+			field.Description = f.Description
+			form.Properties[f.Name] = field
+			if f.Required {
+				form.Required = append(form.Required, f.Name)
+			}
+		}
+		content["multipart/form-data"] = &MediaType{Schema: form}
+	}
+	for _, ct := range r.RawBody {
+		content[ct] = &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	return &RequestBody{Required: true, Content: content}
+}
+
+func (b *Builder) successResponse(r Route, status int) *Response {
+	resp := &Response{Description: http.StatusText(status)}
+	for _, name := range r.Headers {
+		if h, ok := b.headers[name]; ok {
+			if resp.Headers == nil {
+				resp.Headers = make(map[string]*Header)
+			}
+			resp.Headers[name] = h
+		}
+	}
+
+	switch {
+	case r.ContentType != "":
+		resp.Content = map[string]*MediaType{r.ContentType: {Schema: &Schema{Type: "string", Format: "binary"}}}
+	case r.Response == nil:
+		// No body
+	case r.Raw:
+		resp.Content = map[string]*MediaType{"application/json": {Schema: b.schemas.For(r.Response)}}
+	case r.List:
+		envelope := &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"data":  {Type: "array", Items: b.schemas.For(r.Response)},
+				"count": {Type: "integer"},
+			},
+			Required: []string{"data", "count"},
+		}
+		b.addFields(envelope, r.Fields)
+		resp.Content = map[string]*MediaType{"application/json": {Schema: envelope}}
+	default:
+		envelope := &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"data": b.schemas.For(r.Response)},
+			Required:   []string{"data"},
+		}
+		b.addFields(envelope, r.Fields)
+		resp.Content = map[string]*MediaType{"application/json": {Schema: envelope}}
+	}
+	return resp
+}
+
+// addFields adds the extra top-level fields of a route to its envelope
+func (b *Builder) addFields(envelope *Schema, fields map[string]interface{}) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		envelope.Properties[name] = b.schemas.For(fields[name])
+		envelope.Required = append(envelope.Required, name)
+	}
+}
+
+// addErrors groups error codes into one response per status
+func (b *Builder) addErrors(op *Operation, codes []string) error {
+	byStatus := make(map[int][]string)
+	for _, code := range codes {
+		status, ok := b.errorStatus[code]
+		if !ok {
+			return fmt.Errorf("error code %s has no status", code)
+		}
+		if !slices.Contains(byStatus[status], code) {
+			byStatus[status] = append(byStatus[status], code)
+		}
+	}
+
+	for status, codes := range byStatus {
+		sort.Strings(codes)
+		op.Responses[strconv.Itoa(status)] = &Response{
+			Description: fmt.Sprintf("%s: %s", http.StatusText(status), strings.Join(codes, ", ")),
+			Content:     map[string]*MediaType{"application/json": {Schema: b.errorSchema}},
+		}
+	}
+	return nil
+}
+
+func paramSchema(p Param) *Schema {
+	if p.Type == "file" {
+		return &Schema{Type: "string", Format: "binary"}
+	}
+	typ := p.Type
+	if typ == "" {
+		typ = "string"
+	}
+	return &Schema{Type: typ, Enum: p.Enum}
+}
+
+// pathParamType guesses integer for ids and tile coordinates
+func pathParamType(name string) string {
+	switch name {
+	case "id", "z", "x", "y":
+		return "integer"
+	default:
+		return "string"
+	}
+}
+
+// operationID derives a stable id such as getApiOriginsSpecies
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range nonAlphanumeric.Split(path, -1) {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
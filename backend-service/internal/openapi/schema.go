@@ -0,0 +1,172 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// Schemas generates component schemas from Go types by reflection,
+// following encoding/json field naming
+type Schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+// NewSchemas creates an empty schema registry
+func NewSchemas() *Schemas {
+	return &Schemas{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+// Components returns every schema registered so far
+func (s *Schemas) Components() map[string]*Schema {
+	return s.components
+}
+
+// For returns the schema of the type of v. Named structs are registered
+// as components and referenced.
+func (s *Schemas) For(v interface{}) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	return s.schema(reflect.TypeOf(v))
+}
+
+func (s *Schemas) schema(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Pointer {
+		inner := s.schema(t.Elem())
+		if inner.Ref != "" {
+			// $ref siblings are ignored in OpenAPI 3.0
+			return &Schema{AllOf: []*Schema{inner}, Nullable: true}
+		}
+		inner.Nullable = true
+		return inner
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawJSONType:
+		return &Schema{}
+	case t.Kind() != reflect.Interface && t.Implements(marshalerType):
+		// Custom JSON encodings cannot be described by reflection
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer", Format: intFormat(t)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Format: intFormat(t), Minimum: &zero}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	case reflect.Struct:
+		return s.structRef(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func intFormat(t reflect.Type) string {
+	if t.Size() == 8 {
+		return "int64"
+	}
+	return "int32"
+}
+
+// structRef registers a struct as a component and returns a reference
+// to it. Anonymous structs are inlined.
+func (s *Schemas) structRef(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return s.structSchema(t)
+	}
+
+	name, ok := s.names[t]
+	if !ok {
+		name = s.componentName(t)
+		s.names[t] = name
+		s.components[name] = &Schema{} // placeholder for recursive types
+		s.components[name] = s.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName is the Go type name, qualified by its package when two
+// packages declare the same name
+func (s *Schemas) componentName(t reflect.Type) string {
+	name := t.Name()
+	if _, taken := s.components[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	return pkg + "." + name
+}
+
+func (s *Schemas) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.addFields(schema, t)
+	return schema
+}
+
+// addFields adds the JSON fields of t to schema, flattening embedded
+// structs as encoding/json does
+func (s *Schemas) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.addFields(schema, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		schema.Properties[name] = s.schema(f.Type)
+
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often idle buckets are dropped
+const pruneInterval = time.Minute
+
+// MemoryStore keeps buckets in process memory. Limits are per instance.
+type MemoryStore struct {
+	mu         sync.Mutex
+	buckets    map[string]*memoryBucket
+	lastPruned time.Time
+}
+
+type memoryBucket struct {
+	bucket
+	full time.Time // when the bucket will have refilled completely
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*memoryBucket)}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastPruned) >= pruneInterval {
+		s.prune(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{bucket: bucket{tokens: float64(limit.Burst), updated: now}}
+		s.buckets[key] = b
+	}
+
+	r := b.take(limit, now)
+	b.full = now.Add(r.Reset)
+	return r, nil
+}
+
+// prune drops buckets that have refilled, since a new bucket is
+// indistinguishable from a full one
+func (s *MemoryStore) prune(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastPruned = now
+}
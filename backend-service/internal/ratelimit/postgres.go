@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// idleBucketAge is how long an untouched bucket is kept in Postgres. It
+// must exceed the time any configured bucket takes to refill.
+const idleBucketAge = 24 * time.Hour
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so every
+// backend instance shares the same limits
+type PostgresStore struct {
+	db *gorm.DB
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+// NewPostgresStore creates a store backed by db
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take implements Store. The bucket row is locked for the duration of
+// the update so concurrent requests cannot spend the same token.
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.pruneIfDue(now)
+
+	var r Result
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		row := models.RateLimitBucket{Key: key, Tokens: float64(limit.Burst), UpdatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&row).Error; err != nil {
+			return err
+		}
+
+		b := bucket{tokens: row.Tokens, updated: row.UpdatedAt}
+		r = b.take(limit, now)
+		return tx.Model(&models.RateLimitBucket{}).Where("key = ?", key).
+			UpdateColumns(map[string]interface{}{"tokens": b.tokens, "updated_at": b.updated}).Error
+	})
+	return r, err
+}
+
+// pruneIfDue deletes idle buckets in the background at most once per
+// prune interval
+func (s *PostgresStore) pruneIfDue(now time.Time) {
+	s.mu.Lock()
+	due := now.Sub(s.lastPruned) >= pruneInterval
+	if due {
+		s.lastPruned = now
+	}
+	s.mu.Unlock()
+	if !due {
+		return
+	}
+
+	go func() {
+		err := s.db.Where("updated_at < ?", now.Add(-idleBucketAge)).Delete(&models.RateLimitBucket{}).Error
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to prune rate limit buckets")
+		}
+	}()
+}
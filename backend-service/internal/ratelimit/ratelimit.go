@@ -0,0 +1,70 @@
+// Package ratelimit implements token-bucket rate limits over a pluggable
+// bucket store
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit is a token bucket refilled at PerMinute tokens a minute up to
+// Burst tokens
+type Limit struct {
+	PerMinute int
+	Burst     int
+}
+
+// Enabled reports whether the limit throttles anything
+func (l Limit) Enabled() bool {
+	return l.PerMinute > 0 && l.Burst > 0
+}
+
+// rate is the refill rate in tokens per second
+func (l Limit) rate() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until a token is available, when denied
+}
+
+// Store keeps bucket state. Take refills the bucket for key, removes a
+// token if one is available and reports the outcome.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// bucket is the state of one token bucket
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take refills b up to now and removes a token if one is available
+func (b *bucket) take(limit Limit, now time.Time) Result {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.rate())
+	}
+	b.updated = now
+
+	r := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		r.Allowed = true
+	} else {
+		r.RetryAfter = seconds((1 - b.tokens) / limit.rate())
+	}
+	r.Remaining = int(math.Floor(b.tokens))
+	r.Reset = seconds((float64(limit.Burst) - b.tokens) / limit.rate())
+	return r
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,245 @@
+package recommend
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/beanspect/backend-service/internal/models"
+	"github.com/beanspect/backend-service/internal/sensory"
+)
+
+// Similarity factors
+const (
+	FactorTaste    = "taste"
+	FactorCaffeine = "caffeine"
+	FactorAltitude = "altitude"
+	FactorRegion   = "region"
+)
+
+// Weights of each factor in the overall similarity score
+var Weights = map[string]float64{
+	FactorTaste:    0.5,
+	FactorCaffeine: 0.2,
+	FactorAltitude: 0.15,
+	FactorRegion:   0.15,
+}
+
+// Scales at which a gap between two ranges or origins scores zero
+const (
+	caffeineScalePct = 1.5
+	altitudeScaleM   = 1500.0
+	regionScaleKm    = 8000.0
+	earthRadiusKm    = 6371.0
+)
+
+// Species is a catalog species with its optional sensory profile
+type Species struct {
+	Origin  models.SpeciesOrigin
+	Profile *models.SensoryProfile
+}
+
+// Reason explains one factor that made two species similar
+type Reason struct {
+	Factor  string `json:"factor"`
+	Message string `json:"message"`
+}
+
+// Match is a species ranked by similarity to a reference species
+type Match struct {
+	Species    string             `json:"species"`
+	CommonName string             `json:"common_name"`
+	Score      float64            `json:"score"`
+	Factors    map[string]float64 `json:"factors"`
+	Reasons    []Reason           `json:"reasons"`
+}
+
+// Rank scores every candidate other than ref and returns them best first.
+// idx resolves flavor tags to their wheel ancestors so that, for example,
+// berry and citrus fruit count as partly alike.
+func Rank(ref Species, candidates []Species, idx *sensory.Index) []Match {
+	matches := make([]Match, 0, len(candidates))
+	for _, c := range candidates {
+		if c.Origin.Species == ref.Origin.Species {
+			continue
+		}
+		matches = append(matches, Compare(ref, c, idx))
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Species < matches[j].Species
+	})
+	return matches
+}
+
+// Compare scores how similar b is to a on a 0-1 scale
+func Compare(a, b Species, idx *sensory.Index) Match {
+	m := Match{
+		Species:    b.Origin.Species,
+		CommonName: b.Origin.CommonName,
+		Factors:    make(map[string]float64, len(Weights)),
+		Reasons:    []Reason{},
+	}
+
+	var total, weights float64
+	add := func(factor string, score float64, ok bool) {
+		if !ok {
+			return
+		}
+		m.Factors[factor] = round(score)
+		total += Weights[factor] * score
+		weights += Weights[factor]
+	}
+
+	taste, tasteOK := tasteScore(a.Profile, b.Profile, idx, &m.Reasons)
+	add(FactorTaste, taste, tasteOK)
+
+	caffeine, caffeineOK := rangeScore(a.Origin.CaffeineMinPct, a.Origin.CaffeineMaxPct, b.Origin.CaffeineMinPct, b.Origin.CaffeineMaxPct, caffeineScalePct)
+	add(FactorCaffeine, caffeine, caffeineOK)
+	if caffeineOK && caffeine == 1 {
+		m.Reasons = append(m.Reasons, Reason{FactorCaffeine, fmt.Sprintf("Overlapping caffeine content (%.1f-%.1f%%)", *b.Origin.CaffeineMinPct, *b.Origin.CaffeineMaxPct)})
+	}
+
+	altitude, altitudeOK := rangeScore(intToFloat(a.Origin.AltitudeMinM), intToFloat(a.Origin.AltitudeMaxM), intToFloat(b.Origin.AltitudeMinM), intToFloat(b.Origin.AltitudeMaxM), altitudeScaleM)
+	add(FactorAltitude, altitude, altitudeOK)
+	if altitudeOK && altitude == 1 {
+		m.Reasons = append(m.Reasons, Reason{FactorAltitude, fmt.Sprintf("Grown at similar altitudes (%d-%dm)", *b.Origin.AltitudeMinM, *b.Origin.AltitudeMaxM)})
+	}
+
+	region := regionScore(a.Origin, b.Origin)
+	add(FactorRegion, region, true)
+	if sameCountry(a.Origin, b.Origin) {
+		m.Reasons = append(m.Reasons, Reason{FactorRegion, fmt.Sprintf("Also grown in %s", b.Origin.Country)})
+	} else if region >= 0.75 && b.Origin.Country != "" {
+		m.Reasons = append(m.Reasons, Reason{FactorRegion, fmt.Sprintf("Grown nearby in %s", b.Origin.Country)})
+	}
+
+	if weights > 0 {
+		m.Score = round(total / weights)
+	}
+	return m
+}
+
+// tasteScore combines the distance between sensory scores with the
+// overlap of flavor tags and their wheel ancestors
+func tasteScore(a, b *models.SensoryProfile, idx *sensory.Index, reasons *[]Reason) (float64, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+
+	va, vb := sensory.Values(*a), sensory.Values(*b)
+	var sum float64
+	for i := range va {
+		d := va[i] - vb[i]
+		sum += d * d
+		if math.Abs(d) <= 1 {
+			*reasons = append(*reasons, Reason{FactorTaste, fmt.Sprintf("Similar %s (%.1f vs %.1f)", sensory.Axes[i], va[i], vb[i])})
+		}
+	}
+	maxDistance := sensory.MaxScore * math.Sqrt(float64(len(va)))
+	attributes := 1 - math.Sqrt(sum)/maxDistance
+
+	ta, tb := expandTags(a, idx), expandTags(b, idx)
+	var shared []string
+	union := len(ta)
+	for slug := range tb {
+		if ta[slug] {
+			shared = append(shared, slug)
+		} else {
+			union++
+		}
+	}
+	flavors := 0.0
+	if union > 0 {
+		flavors = float64(len(shared)) / float64(union)
+	}
+
+	direct := sharedTags(a, b)
+	if len(direct) > 0 {
+		*reasons = append(*reasons, Reason{FactorTaste, "Shares flavor notes: " + strings.Join(direct, ", ")})
+	}
+
+	return 0.7*attributes + 0.3*flavors, true
+}
+
+// expandTags returns the slugs of a profile's tags and all their ancestors
+func expandTags(p *models.SensoryProfile, idx *sensory.Index) map[string]bool {
+	set := make(map[string]bool)
+	for _, t := range p.FlavorTags {
+		set[t.Slug] = true
+		if idx != nil {
+			for _, slug := range idx.Path(t.ID) {
+				set[slug] = true
+			}
+		}
+	}
+	return set
+}
+
+// sharedTags returns the tag slugs both profiles carry directly
+func sharedTags(a, b *models.SensoryProfile) []string {
+	set := make(map[string]bool, len(a.FlavorTags))
+	for _, t := range a.FlavorTags {
+		set[t.Slug] = true
+	}
+	var shared []string
+	for _, t := range b.FlavorTags {
+		if set[t.Slug] {
+			shared = append(shared, t.Slug)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+// rangeScore is 1 for overlapping ranges and falls off linearly with the
+// gap between them, reaching 0 at scale
+func rangeScore(aMin, aMax, bMin, bMax *float64, scale float64) (float64, bool) {
+	if aMin == nil || aMax == nil || bMin == nil || bMax == nil {
+		return 0, false
+	}
+	gap := math.Max(*bMin-*aMax, *aMin-*bMax)
+	if gap <= 0 {
+		return 1, true
+	}
+	return math.Max(0, 1-gap/scale), true
+}
+
+// regionScore is 1 within the same country and otherwise falls off with
+// the great-circle distance between the origin points
+func regionScore(a, b models.SpeciesOrigin) float64 {
+	if sameCountry(a, b) {
+		return 1
+	}
+	return math.Max(0, 1-haversineKm(a.Latitude, a.Longitude, b.Latitude, b.Longitude)/regionScaleKm)
+}
+
+// sameCountry reports whether both origins name the same country; an
+// unknown country matches nothing
+func sameCountry(a, b models.SpeciesOrigin) bool {
+	return a.Country != "" && b.Country != "" && strings.EqualFold(a.Country, b.Country)
+}
+
+func haversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+func intToFloat(v *int) *float64 {
+	if v == nil {
+		return nil
+	}
+	f := float64(*v)
+	return &f
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
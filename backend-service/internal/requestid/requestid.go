@@ -0,0 +1,49 @@
+// Package requestid carries the ID correlating the logs of a request
+// across services
+package requestid
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Header is the header the ID travels in, to clients and to the services
+// the backend calls
+const Header = "X-Request-ID"
+
+// maxLength bounds IDs accepted from clients
+const maxLength = 128
+
+type contextKey struct{}
+
+// New generates a request ID
+func New() string {
+	return utils.UUIDv4()
+}
+
+// Valid reports whether id, received from a client, may be used as is:
+// printable ASCII without spaces and at most 128 characters, so it is safe
+// to log and to forward in a header
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext returns a copy of ctx carrying id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, if any
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
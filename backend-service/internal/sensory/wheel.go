@@ -0,0 +1,251 @@
+package sensory
+
+import (
+	"sort"
+
+	"github.com/beanspect/backend-service/internal/models"
+)
+
+// WheelNode is a flavor wheel entry used to seed flavor_tags
+type WheelNode struct {
+	Slug     string
+	Name     string
+	Children []WheelNode
+}
+
+// Wheel is the SCA / WCR Coffee Taster's Flavor Wheel, inner ring first
+var Wheel = []WheelNode{
+	{"floral", "Floral", []WheelNode{
+		{"black_tea", "Black Tea", nil},
+		{"floral_notes", "Floral", []WheelNode{
+			{"chamomile", "Chamomile", nil},
+			{"rose", "Rose", nil},
+			{"jasmine", "Jasmine", nil},
+		}},
+	}},
+	{"fruity", "Fruity", []WheelNode{
+		{"berry", "Berry", []WheelNode{
+			{"blackberry", "Blackberry", nil},
+			{"raspberry", "Raspberry", nil},
+			{"blueberry", "Blueberry", nil},
+			{"strawberry", "Strawberry", nil},
+		}},
+		{"dried_fruit", "Dried Fruit", []WheelNode{
+			{"raisin", "Raisin", nil},
+			{"prune", "Prune", nil},
+		}},
+		{"other_fruit", "Other Fruit", []WheelNode{
+			{"coconut", "Coconut", nil},
+			{"cherry", "Cherry", nil},
+			{"pomegranate", "Pomegranate", nil},
+			{"pineapple", "Pineapple", nil},
+			{"grape", "Grape", nil},
+			{"apple", "Apple", nil},
+			{"peach", "Peach", nil},
+			{"pear", "Pear", nil},
+		}},
+		{"citrus_fruit", "Citrus Fruit", []WheelNode{
+			{"grapefruit", "Grapefruit", nil},
+			{"orange", "Orange", nil},
+			{"lemon", "Lemon", nil},
+			{"lime", "Lime", nil},
+		}},
+	}},
+	{"sour_fermented", "Sour/Fermented", []WheelNode{
+		{"sour", "Sour", []WheelNode{
+			{"sour_aromatics", "Sour Aromatics", nil},
+			{"acetic_acid", "Acetic Acid", nil},
+			{"citric_acid", "Citric Acid", nil},
+			{"malic_acid", "Malic Acid", nil},
+		}},
+		{"alcohol_fermented", "Alcohol/Fermented", []WheelNode{
+			{"winey", "Winey", nil},
+			{"whiskey", "Whiskey", nil},
+			{"fermented", "Fermented", nil},
+			{"overripe", "Overripe", nil},
+		}},
+	}},
+	{"green_vegetative", "Green/Vegetative", []WheelNode{
+		{"olive_oil", "Olive Oil", nil},
+		{"raw", "Raw", nil},
+		{"vegetative", "Green/Vegetative", []WheelNode{
+			{"under_ripe", "Under-ripe", nil},
+			{"peapod", "Peapod", nil},
+			{"fresh", "Fresh", nil},
+			{"dark_green", "Dark Green", nil},
+			{"hay_like", "Hay-like", nil},
+			{"herb_like", "Herb-like", nil},
+		}},
+		{"beany", "Beany", nil},
+	}},
+	{"other", "Other", []WheelNode{
+		{"papery_musty", "Papery/Musty", []WheelNode{
+			{"stale", "Stale", nil},
+			{"cardboard", "Cardboard", nil},
+			{"papery", "Papery", nil},
+			{"woody", "Woody", nil},
+			{"moldy_damp", "Moldy/Damp", nil},
+			{"musty_dusty", "Musty/Dusty", nil},
+			{"musty_earthy", "Musty/Earthy", nil},
+			{"animalic", "Animalic", nil},
+			{"meaty_brothy", "Meaty Brothy", nil},
+			{"phenolic", "Phenolic", nil},
+		}},
+		{"chemical", "Chemical", []WheelNode{
+			{"bitter", "Bitter", nil},
+			{"salty", "Salty", nil},
+			{"medicinal", "Medicinal", nil},
+			{"petroleum", "Petroleum", nil},
+			{"skunky", "Skunky", nil},
+			{"rubber", "Rubber", nil},
+		}},
+	}},
+	{"roasted", "Roasted", []WheelNode{
+		{"pipe_tobacco", "Pipe Tobacco", nil},
+		{"tobacco", "Tobacco", nil},
+		{"burnt", "Burnt", []WheelNode{
+			{"acrid", "Acrid", nil},
+			{"ashy", "Ashy", nil},
+			{"smoky", "Smoky", nil},
+			{"brown_roast", "Brown, Roast", nil},
+		}},
+		{"cereal", "Cereal", []WheelNode{
+			{"grain", "Grain", nil},
+			{"malt", "Malt", nil},
+		}},
+	}},
+	{"spices", "Spices", []WheelNode{
+		{"pungent", "Pungent", nil},
+		{"pepper", "Pepper", nil},
+		{"brown_spice", "Brown Spice", []WheelNode{
+			{"anise", "Anise", nil},
+			{"nutmeg", "Nutmeg", nil},
+			{"cinnamon", "Cinnamon", nil},
+			{"clove", "Clove", nil},
+		}},
+	}},
+	{"nutty_cocoa", "Nutty/Cocoa", []WheelNode{
+		{"nutty", "Nutty", []WheelNode{
+			{"peanuts", "Peanuts", nil},
+			{"hazelnut", "Hazelnut", nil},
+			{"almond", "Almond", nil},
+		}},
+		{"cocoa", "Cocoa", []WheelNode{
+			{"chocolate", "Chocolate", nil},
+			{"dark_chocolate", "Dark Chocolate", nil},
+		}},
+	}},
+	{"sweet", "Sweet", []WheelNode{
+		{"brown_sugar", "Brown Sugar", []WheelNode{
+			{"molasses", "Molasses", nil},
+			{"maple_syrup", "Maple Syrup", nil},
+			{"caramelized", "Caramelized", nil},
+			{"honey", "Honey", nil},
+		}},
+		{"vanilla", "Vanilla", nil},
+		{"vanillin", "Vanillin", nil},
+		{"overall_sweet", "Overall Sweet", nil},
+		{"sweet_aromatics", "Sweet Aromatics", nil},
+	}},
+}
+
+// TagNode is a flavor tag with its children, as served to clients
+type TagNode struct {
+	Slug     string     `json:"slug"`
+	Name     string     `json:"name"`
+	Level    int        `json:"level"`
+	Children []*TagNode `json:"children,omitempty"`
+}
+
+// Index provides hierarchy lookups over a loaded set of flavor tags
+type Index struct {
+	byID     map[uint]models.FlavorTag
+	bySlug   map[string]models.FlavorTag
+	children map[uint][]uint
+	roots    []uint
+}
+
+// NewIndex builds an index from every row of flavor_tags
+func NewIndex(tags []models.FlavorTag) *Index {
+	idx := &Index{
+		byID:     make(map[uint]models.FlavorTag, len(tags)),
+		bySlug:   make(map[string]models.FlavorTag, len(tags)),
+		children: make(map[uint][]uint),
+	}
+
+	sorted := append([]models.FlavorTag(nil), tags...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	for _, t := range sorted {
+		idx.byID[t.ID] = t
+		idx.bySlug[t.Slug] = t
+		if t.ParentID == nil {
+			idx.roots = append(idx.roots, t.ID)
+		} else {
+			idx.children[*t.ParentID] = append(idx.children[*t.ParentID], t.ID)
+		}
+	}
+	return idx
+}
+
+// Lookup returns the tag with the given slug
+func (idx *Index) Lookup(slug string) (models.FlavorTag, bool) {
+	t, ok := idx.bySlug[slug]
+	return t, ok
+}
+
+// Descendants returns the ids of a tag and every tag beneath it
+func (idx *Index) Descendants(id uint) []uint {
+	out := []uint{id}
+	for i := 0; i < len(out); i++ {
+		out = append(out, idx.children[out[i]]...)
+	}
+	return out
+}
+
+// Path returns the slugs from the inner ring down to the tag
+func (idx *Index) Path(id uint) []string {
+	var path []string
+	for {
+		t, ok := idx.byID[id]
+		if !ok {
+			break
+		}
+		path = append([]string{t.Slug}, path...)
+		if t.ParentID == nil {
+			break
+		}
+		id = *t.ParentID
+	}
+	return path
+}
+
+// Tree returns the whole wheel as nested nodes
+func (idx *Index) Tree() []*TagNode {
+	var build func(id uint) *TagNode
+	build = func(id uint) *TagNode {
+		t := idx.byID[id]
+		node := &TagNode{Slug: t.Slug, Name: t.Name, Level: t.Level}
+		for _, child := range idx.children[id] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+
+	nodes := make([]*TagNode, 0, len(idx.roots))
+	for _, id := range idx.roots {
+		nodes = append(nodes, build(id))
+	}
+	return nodes
+}
+
+// MaxScore is the top of the 0-10 sensory attribute scale
+const MaxScore = 10.0
+
+// Axes are the numeric attributes plotted on a radar chart, in order
+var Axes = []string{"acidity", "body", "sweetness", "bitterness"}
+
+// Values returns the profile scores in Axes order
+func Values(p models.SensoryProfile) []float64 {
+	return []float64{p.Acidity, p.Body, p.Sweetness, p.Bitterness}
+}
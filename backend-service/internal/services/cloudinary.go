@@ -8,6 +8,7 @@ import (
 
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
@@ -46,11 +47,11 @@ func (s *CloudinaryService) UploadImage(ctx context.Context, file io.Reader, fil
 
 	result, err := s.cld.Upload.Upload(ctx, file, uploadParams)
 	if err != nil {
-		log.Error().Err(err).Str("filename", filename).Msg("Failed to upload image to Cloudinary")
+		zerolog.Ctx(ctx).Error().Err(err).Str("filename", filename).Msg("Failed to upload image to Cloudinary")
 		return nil, fmt.Errorf("failed to upload image: %w", err)
 	}
 
-	log.Info().
+	zerolog.Ctx(ctx).Info().
 		Str("public_id", result.PublicID).
 		Str("url", result.SecureURL).
 		Msg("Image uploaded to Cloudinary")
@@ -99,6 +100,6 @@ func (s *CloudinaryService) DeleteImage(ctx context.Context, publicID string) er
 	if err != nil {
 		return fmt.Errorf("failed to delete image: %w", err)
 	}
-	log.Info().Str("public_id", publicID).Msg("Image deleted from Cloudinary")
+	zerolog.Ctx(ctx).Info().Str("public_id", publicID).Msg("Image deleted from Cloudinary")
 	return nil
 }
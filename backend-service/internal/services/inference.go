@@ -2,7 +2,9 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -11,7 +13,15 @@ import (
 	"time"
 
 	"github.com/beanspect/backend-service/internal/config"
-	"github.com/rs/zerolog/log"
+	"github.com/beanspect/backend-service/internal/metrics"
+	"github.com/beanspect/backend-service/internal/requestid"
+	"github.com/beanspect/backend-service/internal/tracing"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ClassPrediction represents a single prediction class
@@ -34,6 +44,42 @@ type ErrorResponse struct {
 	Message string `json:"message"`
 }
 
+// InferenceError is an error response of the inference service. Code and
+// Message are empty when the body is not a standard error response.
+type InferenceError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *InferenceError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("inference service returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("inference service returned status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// newInferenceError reads an error response, either the standard body or
+// the same body nested under FastAPI's detail key
+func newInferenceError(status int, body []byte) *InferenceError {
+	e := &InferenceError{StatusCode: status}
+	var resp struct {
+		ErrorResponse
+		Detail *ErrorResponse `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return e
+	}
+	errResp := resp.ErrorResponse
+	if resp.Detail != nil {
+		errResp = *resp.Detail
+	}
+	if errResp.Error {
+		e.Code, e.Message = errResp.Code, errResp.Message
+	}
+	return e
+}
+
 // InferenceClient handles communication with the inference service
 type InferenceClient struct {
 	baseURL    string
@@ -51,8 +97,55 @@ func NewInferenceClient() *InferenceClient {
 	}
 }
 
-// Predict sends an image to the inference service for classification
-func (c *InferenceClient) Predict(filename string, fileContent []byte) (*PredictionResponse, error) {
+// Predict sends an image to the inference service for classification.
+// The request ID and trace context carried by ctx are forwarded so both
+// services log the request and trace it as one.
+func (c *InferenceClient) Predict(ctx context.Context, filename string, fileContent []byte) (*PredictionResponse, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "inference.predict",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(http.MethodPost),
+			semconv.URLFull(c.baseURL+"/predict"),
+			attribute.Int("beanspect.image.size", len(fileContent)),
+		),
+	)
+
+	start := time.Now()
+	prediction, err := c.predict(ctx, filename, fileContent)
+	metrics.ObserveInference(time.Since(start), errorCode(err))
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+	metrics.ObservePrediction(prediction.PredictedClass, prediction.Confidence)
+
+	span.SetAttributes(
+		attribute.String("beanspect.prediction.species", prediction.PredictedClass),
+		attribute.Float64("beanspect.prediction.confidence", prediction.Confidence),
+	)
+	span.End()
+	return prediction, nil
+}
+
+// errorCode labels a failed prediction for metrics: the upstream error
+// code, HTTP_<status> for non-standard error responses and UNAVAILABLE
+// when no response was received
+func errorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	var upstream *InferenceError
+	if !errors.As(err, &upstream) {
+		return "UNAVAILABLE"
+	}
+	if upstream.Code == "" {
+		return fmt.Sprintf("HTTP_%d", upstream.StatusCode)
+	}
+	return upstream.Code
+}
+
+func (c *InferenceClient) predict(ctx context.Context, filename string, fileContent []byte) (*PredictionResponse, error) {
+	logger := zerolog.Ctx(ctx)
 	url := fmt.Sprintf("%s/predict", c.baseURL)
 
 	// Create multipart form data
@@ -87,13 +180,17 @@ func (c *InferenceClient) Predict(filename string, fileContent []byte) (*Predict
 	}
 
 	// Create request
-	req, err := http.NewRequest("POST", url, body)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if id := requestid.FromContext(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
-	log.Info().
+	logger.Info().
 		Str("url", url).
 		Str("filename", filename).
 		Msg("Sending prediction request to inference service")
@@ -104,6 +201,7 @@ func (c *InferenceClient) Predict(filename string, fileContent []byte) (*Predict
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
+	trace.SpanFromContext(ctx).SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
 
 	// Read response body
 	respBody, err := io.ReadAll(resp.Body)
@@ -113,15 +211,11 @@ func (c *InferenceClient) Predict(filename string, fileContent []byte) (*Predict
 
 	// Check for errors
 	if resp.StatusCode != http.StatusOK {
-		log.Error().
+		logger.Error().
 			Int("status_code", resp.StatusCode).
 			Str("response_body", string(respBody)).
 			Msg("Inference service error response")
-		var errResp ErrorResponse
-		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.Error {
-			return nil, fmt.Errorf("%s: %s", errResp.Code, errResp.Message)
-		}
-		return nil, fmt.Errorf("inference service returned status %d: %s", resp.StatusCode, string(respBody))
+		return nil, newInferenceError(resp.StatusCode, respBody)
 	}
 
 	// Parse prediction response
@@ -130,7 +224,7 @@ func (c *InferenceClient) Predict(filename string, fileContent []byte) (*Predict
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	log.Info().
+	logger.Info().
 		Str("predicted_class", prediction.PredictedClass).
 		Float64("confidence", prediction.Confidence).
 		Msg("Received prediction from inference service")
@@ -139,10 +233,14 @@ func (c *InferenceClient) Predict(filename string, fileContent []byte) (*Predict
 }
 
 // HealthCheck checks if the inference service is healthy
-func (c *InferenceClient) HealthCheck() (bool, error) {
+func (c *InferenceClient) HealthCheck(ctx context.Context) (bool, error) {
 	url := fmt.Sprintf("%s/health", c.baseURL)
 
-	resp, err := c.httpClient.Get(url)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return false, err
 	}
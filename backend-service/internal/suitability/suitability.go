@@ -0,0 +1,185 @@
+// Package suitability scores how well catalog species suit a growing site
+package suitability
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/beanspect/backend-service/internal/models"
+)
+
+// Constraint names
+const (
+	ConstraintAltitude    = "altitude"
+	ConstraintLatitude    = "latitude"
+	ConstraintTemperature = "temperature"
+	ConstraintRainfall    = "rainfall"
+)
+
+// Constraint outcomes
+const (
+	StatusPass     = "pass"
+	StatusMarginal = "marginal"
+	StatusFail     = "fail"
+	StatusUnknown  = "unknown"
+)
+
+// Weights of each constraint in the overall score
+var Weights = map[string]float64{
+	ConstraintAltitude:    0.4,
+	ConstraintLatitude:    0.2,
+	ConstraintTemperature: 0.25,
+	ConstraintRainfall:    0.15,
+}
+
+// Margins outside a species range within which a site is still marginal
+const (
+	altitudeMarginM  = 300.0
+	latitudeMargin   = 5.0
+	temperatureMargC = 3.0
+	rainfallMarginMm = 500.0
+)
+
+// SuitableScore is the minimum score for a species to be called suitable
+const SuitableScore = 0.7
+
+// Site describes a growing location. Optional fields are nil when unknown.
+type Site struct {
+	Latitude   float64
+	Longitude  float64
+	ElevationM *float64
+	TempC      *float64
+	RainfallMm *float64
+}
+
+// Check is the outcome of one constraint
+type Check struct {
+	Constraint string   `json:"constraint"`
+	Status     string   `json:"status"`
+	Score      *float64 `json:"score"`
+	Message    string   `json:"message"`
+}
+
+// Result is the suitability of one species for a site
+type Result struct {
+	Species    string  `json:"species"`
+	CommonName string  `json:"common_name"`
+	Score      float64 `json:"score"`
+	Suitable   bool    `json:"suitable"`
+	Checks     []Check `json:"constraints"`
+}
+
+// Rank scores every species for the site, best first
+func Rank(site Site, origins []models.SpeciesOrigin) []Result {
+	results := make([]Result, len(origins))
+	for i, o := range origins {
+		results[i] = Evaluate(site, o)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Species < results[j].Species
+	})
+	return results
+}
+
+// Evaluate scores one species for the site. Constraints without data on
+// either side are reported as unknown and left out of the score.
+func Evaluate(site Site, o models.SpeciesOrigin) Result {
+	checks := []Check{
+		altitudeCheck(site, o),
+		latitudeCheck(site, o),
+		temperatureCheck(site, o),
+		rainfallCheck(site, o),
+	}
+
+	var total, weights float64
+	for _, c := range checks {
+		if c.Score == nil {
+			continue
+		}
+		total += Weights[c.Constraint] * *c.Score
+		weights += Weights[c.Constraint]
+	}
+
+	r := Result{Species: o.Species, CommonName: o.CommonName, Checks: checks}
+	if weights > 0 {
+		r.Score = math.Round(total/weights*1000) / 1000
+	}
+	r.Suitable = weights > 0 && r.Score >= SuitableScore
+	for _, c := range checks {
+		if c.Status == StatusFail {
+			r.Suitable = false
+		}
+	}
+	return r
+}
+
+func altitudeCheck(site Site, o models.SpeciesOrigin) Check {
+	if site.ElevationM == nil {
+		return unknown(ConstraintAltitude, "Site elevation is unknown")
+	}
+	if o.AltitudeMinM == nil || o.AltitudeMaxM == nil {
+		return unknown(ConstraintAltitude, "No altitude range recorded for this species")
+	}
+	return rangeCheck(ConstraintAltitude, *site.ElevationM, float64(*o.AltitudeMinM), float64(*o.AltitudeMaxM), altitudeMarginM,
+		"elevation %.0fm", "%.0f-%.0fm")
+}
+
+func latitudeCheck(site Site, o models.SpeciesOrigin) Check {
+	if o.LatitudeBelt == nil {
+		return unknown(ConstraintLatitude, "No latitude belt recorded for this species")
+	}
+	return rangeCheck(ConstraintLatitude, math.Abs(site.Latitude), 0, *o.LatitudeBelt, latitudeMargin,
+		"latitude %.1f°", "the 0-%[2]g° belt")
+}
+
+func temperatureCheck(site Site, o models.SpeciesOrigin) Check {
+	if site.TempC == nil {
+		return unknown(ConstraintTemperature, "Site mean temperature was not supplied")
+	}
+	if o.TempMinC == nil || o.TempMaxC == nil {
+		return unknown(ConstraintTemperature, "No temperature range recorded for this species")
+	}
+	return rangeCheck(ConstraintTemperature, *site.TempC, *o.TempMinC, *o.TempMaxC, temperatureMargC,
+		"mean temperature %.1f°C", "%g-%g°C")
+}
+
+func rainfallCheck(site Site, o models.SpeciesOrigin) Check {
+	if site.RainfallMm == nil {
+		return unknown(ConstraintRainfall, "Site annual rainfall was not supplied")
+	}
+	if o.RainfallMinMm == nil || o.RainfallMaxMm == nil {
+		return unknown(ConstraintRainfall, "No rainfall range recorded for this species")
+	}
+	return rangeCheck(ConstraintRainfall, *site.RainfallMm, float64(*o.RainfallMinMm), float64(*o.RainfallMaxMm), rainfallMarginMm,
+		"annual rainfall %.0fmm", "%.0f-%.0fmm")
+}
+
+// rangeCheck passes values within [min, max], scores values within margin
+// of the range linearly down to zero and fails anything further out
+func rangeCheck(constraint string, value, min, max, margin float64, valueFormat, rangeFormat string) Check {
+	site := fmt.Sprintf(valueFormat, value)
+	want := fmt.Sprintf(rangeFormat, min, max)
+
+	gap := math.Max(min-value, value-max)
+	switch {
+	case gap <= 0:
+		return checked(constraint, StatusPass, 1, "Site %s is within %s", site, want)
+	case gap <= margin:
+		return checked(constraint, StatusMarginal, 1-gap/margin, "Site %s is just outside %s", site, want)
+	default:
+		return checked(constraint, StatusFail, 0, "Site %s is outside %s", site, want)
+	}
+}
+
+func checked(constraint, status string, score float64, format string, args ...interface{}) Check {
+	score = math.Round(score*1000) / 1000
+	return Check{Constraint: constraint, Status: status, Score: &score, Message: fmt.Sprintf(format, args...)}
+}
+
+func unknown(constraint, message string) Check {
+	return Check{Constraint: constraint, Status: StatusUnknown, Message: message}
+}
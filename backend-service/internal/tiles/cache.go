@@ -0,0 +1,109 @@
+package tiles
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// CachedTile is an encoded tile together with its strong ETag
+type CachedTile struct {
+	Data []byte
+	ETag string
+}
+
+// NewCachedTile wraps encoded tile data and computes its ETag
+func NewCachedTile(data []byte) *CachedTile {
+	sum := sha1.Sum(data)
+	return &CachedTile{
+		Data: data,
+		ETag: `"` + hex.EncodeToString(sum[:]) + `"`,
+	}
+}
+
+// Cache is a size-bounded LRU cache of rendered tiles
+type Cache struct {
+	mu      sync.Mutex
+	maxSize int
+	ll      *list.List
+	items   map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key  string
+	tile *CachedTile
+}
+
+// NewCache creates a tile cache holding at most maxSize tiles.
+// A non-positive size disables caching.
+func NewCache(maxSize int) *Cache {
+	return &Cache{
+		maxSize: maxSize,
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+	}
+}
+
+// Key builds the cache key for a layer tile
+func Key(layer string, z, x, y int) string {
+	return fmt.Sprintf("%s/%d/%d/%d", layer, z, x, y)
+}
+
+// Get returns a cached tile and marks it as recently used
+func (c *Cache) Get(key string) (*CachedTile, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		return el.Value.(*cacheEntry).tile, true
+	}
+	return nil, false
+}
+
+// Set stores a tile, evicting the least recently used entry when full
+func (c *Cache) Set(key string, tile *CachedTile) {
+	if c.maxSize <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		el.Value.(*cacheEntry).tile = tile
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, tile: tile})
+	for c.ll.Len() > c.maxSize {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// PurgeLayer drops every cached tile belonging to a layer
+func (c *Cache) PurgeLayer(layer string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefix := layer + "/"
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.ll.Remove(el)
+			delete(c.items, key)
+		}
+	}
+}
+
+// Len returns the number of cached tiles
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
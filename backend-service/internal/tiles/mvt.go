@@ -0,0 +1,282 @@
+package tiles
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DefaultExtent is the number of integer units across a tile
+const DefaultExtent = 4096
+
+// GeomType is the Mapbox Vector Tile geometry type
+type GeomType int
+
+// Geometry types defined by the MVT 2.1 specification
+const (
+	GeomPoint      GeomType = 1
+	GeomLineString GeomType = 2
+	GeomPolygon    GeomType = 3
+)
+
+// TileCoord is an integer position inside a tile
+type TileCoord [2]int
+
+// Feature is a single feature already projected into tile coordinates.
+// For points Parts holds one entry with every point, for polygons each
+// part is a ring, exterior rings followed by their holes.
+type Feature struct {
+	ID         uint64
+	Type       GeomType
+	Parts      [][]TileCoord
+	Properties map[string]interface{}
+}
+
+// Layer is a named set of features within a tile
+type Layer struct {
+	Name     string
+	Extent   int
+	Features []Feature
+}
+
+// Protobuf field numbers from vector_tile.proto
+const (
+	tileLayers = 3
+
+	layerVersion  = 15
+	layerName     = 1
+	layerFeatures = 2
+	layerKeys     = 3
+	layerValues   = 4
+	layerExtent   = 5
+
+	featureID       = 1
+	featureTags     = 2
+	featureType     = 3
+	featureGeometry = 4
+
+	valueString = 1
+	valueDouble = 3
+	valueInt    = 4
+	valueUint   = 5
+	valueBool   = 7
+)
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// Geometry command identifiers
+const (
+	cmdMoveTo    = 1
+	cmdLineTo    = 2
+	cmdClosePath = 7
+)
+
+// Encode serializes layers into a Mapbox Vector Tile protobuf message.
+// Property keys are written in sorted order so output is deterministic.
+func Encode(layers ...Layer) []byte {
+	var tile pbuf
+	for _, l := range layers {
+		if len(l.Features) == 0 {
+			continue
+		}
+		tile.bytes(tileLayers, encodeLayer(l))
+	}
+	return tile
+}
+
+func encodeLayer(l Layer) []byte {
+	extent := l.Extent
+	if extent == 0 {
+		extent = DefaultExtent
+	}
+
+	var keys []string
+	keyIndex := map[string]uint32{}
+	var values [][]byte
+	valueIndex := map[string]uint32{}
+
+	var layer pbuf
+	layer.varint(layerVersion, 2)
+	layer.str(layerName, l.Name)
+
+	for _, f := range l.Features {
+		geometry := encodeGeometry(f)
+		if len(geometry) == 0 {
+			continue
+		}
+
+		names := make([]string, 0, len(f.Properties))
+		for k, v := range f.Properties {
+			if v != nil {
+				names = append(names, k)
+			}
+		}
+		sort.Strings(names)
+
+		tags := make([]uint32, 0, len(names)*2)
+		for _, k := range names {
+			encoded := encodeValue(f.Properties[k])
+			ki, ok := keyIndex[k]
+			if !ok {
+				ki = uint32(len(keys))
+				keyIndex[k] = ki
+				keys = append(keys, k)
+			}
+			vi, ok := valueIndex[string(encoded)]
+			if !ok {
+				vi = uint32(len(values))
+				valueIndex[string(encoded)] = vi
+				values = append(values, encoded)
+			}
+			tags = append(tags, ki, vi)
+		}
+
+		var feature pbuf
+		if f.ID != 0 {
+			feature.varint(featureID, f.ID)
+		}
+		if len(tags) > 0 {
+			feature.packed(featureTags, tags)
+		}
+		feature.varint(featureType, uint64(f.Type))
+		feature.packed(featureGeometry, geometry)
+		layer.bytes(layerFeatures, feature)
+	}
+
+	for _, k := range keys {
+		layer.str(layerKeys, k)
+	}
+	for _, v := range values {
+		layer.bytes(layerValues, v)
+	}
+	layer.varint(layerExtent, uint64(extent))
+
+	return layer
+}
+
+func encodeValue(v interface{}) []byte {
+	var p pbuf
+	switch val := v.(type) {
+	case string:
+		p.str(valueString, val)
+	case bool:
+		b := uint64(0)
+		if val {
+			b = 1
+		}
+		p.varint(valueBool, b)
+	case int:
+		p.varint(valueInt, uint64(int64(val)))
+	case int32:
+		p.varint(valueInt, uint64(int64(val)))
+	case int64:
+		p.varint(valueInt, uint64(val))
+	case uint:
+		p.varint(valueUint, uint64(val))
+	case uint32:
+		p.varint(valueUint, uint64(val))
+	case uint64:
+		p.varint(valueUint, val)
+	case float32:
+		p.double(valueDouble, float64(val))
+	case float64:
+		p.double(valueDouble, val)
+	default:
+		p.str(valueString, fmt.Sprint(val))
+	}
+	return p
+}
+
+// encodeGeometry turns feature parts into MVT command integers
+func encodeGeometry(f Feature) []uint32 {
+	var out []uint32
+	var cx, cy int
+
+	switch f.Type {
+	case GeomPoint:
+		if len(f.Parts) == 0 || len(f.Parts[0]) == 0 {
+			return nil
+		}
+		points := f.Parts[0]
+		out = append(out, command(cmdMoveTo, len(points)))
+		for _, p := range points {
+			out = append(out, zigzag(p[0]-cx), zigzag(p[1]-cy))
+			cx, cy = p[0], p[1]
+		}
+	case GeomLineString, GeomPolygon:
+		for _, part := range f.Parts {
+			pts := part
+			if f.Type == GeomPolygon {
+				if len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+					pts = pts[:len(pts)-1]
+				}
+				if len(pts) < 3 {
+					continue
+				}
+			} else if len(pts) < 2 {
+				continue
+			}
+			out = append(out, command(cmdMoveTo, 1), zigzag(pts[0][0]-cx), zigzag(pts[0][1]-cy))
+			cx, cy = pts[0][0], pts[0][1]
+			out = append(out, command(cmdLineTo, len(pts)-1))
+			for _, p := range pts[1:] {
+				out = append(out, zigzag(p[0]-cx), zigzag(p[1]-cy))
+				cx, cy = p[0], p[1]
+			}
+			if f.Type == GeomPolygon {
+				out = append(out, command(cmdClosePath, 1))
+			}
+		}
+	}
+	return out
+}
+
+func command(id, count int) uint32 {
+	return uint32(id&0x7) | uint32(count)<<3
+}
+
+func zigzag(n int) uint32 {
+	v := int32(n)
+	return uint32((v << 1) ^ (v >> 31))
+}
+
+// pbuf is a minimal protobuf writer covering the wire types MVT needs
+type pbuf []byte
+
+func (p *pbuf) key(field, wire int) {
+	*p = binary.AppendUvarint(*p, uint64(field<<3|wire))
+}
+
+func (p *pbuf) varint(field int, v uint64) {
+	p.key(field, wireVarint)
+	*p = binary.AppendUvarint(*p, v)
+}
+
+func (p *pbuf) double(field int, v float64) {
+	p.key(field, wireFixed64)
+	*p = binary.LittleEndian.AppendUint64(*p, math.Float64bits(v))
+}
+
+func (p *pbuf) bytes(field int, b []byte) {
+	p.key(field, wireBytes)
+	*p = binary.AppendUvarint(*p, uint64(len(b)))
+	*p = append(*p, b...)
+}
+
+func (p *pbuf) str(field int, s string) {
+	p.bytes(field, []byte(s))
+}
+
+func (p *pbuf) packed(field int, vs []uint32) {
+	var buf []byte
+	for _, v := range vs {
+		buf = binary.AppendUvarint(buf, uint64(v))
+	}
+	p.bytes(field, buf)
+}
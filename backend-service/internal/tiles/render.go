@@ -0,0 +1,218 @@
+package tiles
+
+import (
+	"math"
+
+	"github.com/beanspect/backend-service/internal/geo"
+)
+
+// DefaultBuffer is the number of tile units rendered beyond each tile edge
+// so symbols and polygon strokes do not get cut at tile boundaries
+const DefaultBuffer = 64
+
+// SourceFeature is a feature in geographic coordinates as returned by a
+// Source. Exactly one of Point or Polygons should be set.
+type SourceFeature struct {
+	ID         uint64
+	Point      *geo.Point
+	Polygons   []geo.Polygon
+	Properties map[string]interface{}
+}
+
+// Source describes one vector tile layer and how to load its features
+type Source struct {
+	Name        string
+	Description string
+	MinZoom     int
+	MaxZoom     int
+
+	// Fields maps attribute names to a short type description for TileJSON
+	Fields map[string]string
+
+	// Fetch returns the features intersecting bounds at zoom z
+	Fetch func(bounds geo.BBox, z int) ([]SourceFeature, error)
+}
+
+// Render builds the encoded tile z/x/y for a source
+func Render(src *Source, z, x, y int) ([]byte, error) {
+	if z < src.MinZoom || z > src.MaxZoom {
+		return Encode(), nil
+	}
+
+	bounds := geo.TileBounds(z, x, y)
+	bufferDeg := (bounds.MaxLng - bounds.MinLng) * DefaultBuffer / DefaultExtent
+	features, err := src.Fetch(bounds.Expand(bufferDeg), z)
+	if err != nil {
+		return nil, err
+	}
+
+	layer := Layer{Name: src.Name, Extent: DefaultExtent}
+	occupied := map[TileCoord]bool{}
+	cell := pointCellSize(z)
+
+	for _, sf := range features {
+		switch {
+		case sf.Point != nil:
+			px, py := geo.ToTilePixel(*sf.Point, z, x, y, DefaultExtent)
+			coord := TileCoord{int(math.Round(px)), int(math.Round(py))}
+			if !inBuffer(coord) {
+				continue
+			}
+			// Thin out points that would overlap at low zooms
+			key := TileCoord{coord[0] / cell, coord[1] / cell}
+			if occupied[key] {
+				continue
+			}
+			occupied[key] = true
+			layer.Features = append(layer.Features, Feature{
+				ID:         sf.ID,
+				Type:       GeomPoint,
+				Parts:      [][]TileCoord{{coord}},
+				Properties: sf.Properties,
+			})
+		case len(sf.Polygons) > 0:
+			parts := projectPolygons(sf.Polygons, z, x, y)
+			if len(parts) == 0 {
+				continue
+			}
+			layer.Features = append(layer.Features, Feature{
+				ID:         sf.ID,
+				Type:       GeomPolygon,
+				Parts:      parts,
+				Properties: sf.Properties,
+			})
+		}
+	}
+
+	return Encode(layer), nil
+}
+
+// pointCellSize returns the thinning grid size in tile units, shrinking
+// as zoom increases until every distinct point is kept
+func pointCellSize(z int) int {
+	switch {
+	case z <= 2:
+		return 64
+	case z <= 5:
+		return 16
+	case z <= 8:
+		return 4
+	default:
+		return 1
+	}
+}
+
+func inBuffer(c TileCoord) bool {
+	return c[0] >= -DefaultBuffer && c[0] <= DefaultExtent+DefaultBuffer &&
+		c[1] >= -DefaultBuffer && c[1] <= DefaultExtent+DefaultBuffer
+}
+
+// projectPolygons simplifies, projects and clips polygons into tile units
+func projectPolygons(polys []geo.Polygon, z, x, y int) [][]TileCoord {
+	tolerance := geo.DegreesPerPixel(z) / 2
+	var parts [][]TileCoord
+
+	for _, poly := range geo.SimplifyPolygons(polys, tolerance) {
+		for i, ring := range poly {
+			projected := make([][2]float64, 0, len(ring))
+			for _, p := range ring {
+				px, py := geo.ToTilePixel(p, z, x, y, DefaultExtent)
+				projected = append(projected, [2]float64{px, py})
+			}
+
+			clipped := clipRing(projected, -DefaultBuffer, DefaultExtent+DefaultBuffer)
+			coords := roundRing(clipped)
+			if len(coords) < 3 {
+				if i == 0 {
+					break
+				}
+				continue
+			}
+
+			// Exterior rings must have positive area, holes negative
+			area := ringArea(coords)
+			if (i == 0 && area < 0) || (i > 0 && area > 0) {
+				reverse(coords)
+			}
+			parts = append(parts, coords)
+		}
+	}
+	return parts
+}
+
+// clipRing clips a ring to the square [min, max] with Sutherland-Hodgman
+func clipRing(ring [][2]float64, min, max float64) [][2]float64 {
+	edges := []struct {
+		inside    func(p [2]float64) bool
+		intersect func(a, b [2]float64) [2]float64
+	}{
+		{func(p [2]float64) bool { return p[0] >= min }, func(a, b [2]float64) [2]float64 { return lerpX(a, b, min) }},
+		{func(p [2]float64) bool { return p[0] <= max }, func(a, b [2]float64) [2]float64 { return lerpX(a, b, max) }},
+		{func(p [2]float64) bool { return p[1] >= min }, func(a, b [2]float64) [2]float64 { return lerpY(a, b, min) }},
+		{func(p [2]float64) bool { return p[1] <= max }, func(a, b [2]float64) [2]float64 { return lerpY(a, b, max) }},
+	}
+
+	out := ring
+	for _, e := range edges {
+		if len(out) == 0 {
+			return nil
+		}
+		in := out
+		out = make([][2]float64, 0, len(in))
+		prev := in[len(in)-1]
+		for _, cur := range in {
+			if e.inside(cur) {
+				if !e.inside(prev) {
+					out = append(out, e.intersect(prev, cur))
+				}
+				out = append(out, cur)
+			} else if e.inside(prev) {
+				out = append(out, e.intersect(prev, cur))
+			}
+			prev = cur
+		}
+	}
+	return out
+}
+
+func lerpX(a, b [2]float64, x float64) [2]float64 {
+	t := (x - a[0]) / (b[0] - a[0])
+	return [2]float64{x, a[1] + t*(b[1]-a[1])}
+}
+
+func lerpY(a, b [2]float64, y float64) [2]float64 {
+	t := (y - a[1]) / (b[1] - a[1])
+	return [2]float64{a[0] + t*(b[0]-a[0]), y}
+}
+
+// roundRing snaps a ring to integer coordinates, dropping repeated points
+func roundRing(ring [][2]float64) []TileCoord {
+	out := make([]TileCoord, 0, len(ring))
+	for _, p := range ring {
+		c := TileCoord{int(math.Round(p[0])), int(math.Round(p[1]))}
+		if len(out) > 0 && out[len(out)-1] == c {
+			continue
+		}
+		out = append(out, c)
+	}
+	if len(out) > 1 && out[0] == out[len(out)-1] {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// ringArea returns the signed surveyor's formula area in tile units
+func ringArea(r []TileCoord) int {
+	sum := 0
+	for i := range r {
+		j := (i + 1) % len(r)
+		sum += r[i][0]*r[j][1] - r[j][0]*r[i][1]
+	}
+	return sum
+}
+
+func reverse(r []TileCoord) {
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+}
@@ -0,0 +1,60 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are exported over
+// OTLP when an endpoint is configured and are not recorded otherwise.
+package tracing
+
+import (
+	"context"
+
+	"github.com/beanspect/backend-service/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans created by this service
+const instrumentationName = "github.com/beanspect/backend-service"
+
+// Tracer returns the tracer spans of the service are started with
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup installs W3C trace context propagation and, when an OTLP endpoint
+// is configured, a tracer provider exporting to it. The returned function
+// flushes pending spans and stops the exporter.
+func Setup(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if cfg.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+	if err != nil {
+		return nil, err
+	}
+	return install(sdktrace.WithBatcher(exporter), cfg.OTELServiceName, cfg.AppVersion), nil
+}
+
+func install(exporter sdktrace.TracerProviderOption, serviceName, version string) func(context.Context) error {
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(version),
+	)
+	provider := sdktrace.NewTracerProvider(exporter, sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown
+}
+
+// End marks span failed when err is set and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
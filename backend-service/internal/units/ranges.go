@@ -0,0 +1,87 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// metresPerFoot converts altitudes given in feet
+const metresPerFoot = 0.3048
+
+var (
+	// "1.2-1.5%", "1.2 – 1.5 %", "1.2 to 1.5%"
+	percentRange = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*%?\s*(?:-|–|—|to)\s*(\d+(?:\.\d+)?)\s*%`)
+	// "2.5%"
+	percentSingle = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*%`)
+
+	// "1000-2000m", "1,000 – 2,000 masl", "3000-6000 ft"
+	altitudeRange = regexp.MustCompile(`(?i)(\d[\d,.]*)\s*(?:m|ft)?\s*(?:-|–|—|to)\s*(\d[\d,.]*)\s*(m|masl|mdpl|meters|metres|ft|feet)?\b`)
+	// "1200m", "above 1500 masl"
+	altitudeSingle = regexp.MustCompile(`(?i)(\d[\d,.]*)\s*(m|masl|mdpl|meters|metres|ft|feet)?\b`)
+)
+
+// ParseCaffeine extracts a caffeine percentage range from text such as
+// "Low to Medium (1.2-1.5%)". A single value yields min == max.
+func ParseCaffeine(s string) (min, max float64, err error) {
+	if m := percentRange.FindStringSubmatch(s); m != nil {
+		min, _ = strconv.ParseFloat(m[1], 64)
+		max, _ = strconv.ParseFloat(m[2], 64)
+		return math.Min(min, max), math.Max(min, max), checkPercent(min, max)
+	}
+	if m := percentSingle.FindStringSubmatch(s); m != nil {
+		v, _ := strconv.ParseFloat(m[1], 64)
+		return v, v, checkPercent(v, v)
+	}
+	return 0, 0, fmt.Errorf("no caffeine percentage found in %q", s)
+}
+
+// ParseAltitude extracts an altitude range in metres from text such as
+// "1000-2000m". Values in feet are converted. A single value yields min == max.
+func ParseAltitude(s string) (min, max int, err error) {
+	if m := altitudeRange.FindStringSubmatch(s); m != nil {
+		lo, err1 := parseNumber(m[1])
+		hi, err2 := parseNumber(m[2])
+		if err1 == nil && err2 == nil {
+			lo, hi = toMetres(lo, m[3]), toMetres(hi, m[3])
+			return int(math.Round(math.Min(lo, hi))), int(math.Round(math.Max(lo, hi))), checkAltitude(lo, hi)
+		}
+	}
+	if m := altitudeSingle.FindStringSubmatch(s); m != nil {
+		v, err := parseNumber(m[1])
+		if err == nil {
+			v = toMetres(v, m[2])
+			return int(math.Round(v)), int(math.Round(v)), checkAltitude(v, v)
+		}
+	}
+	return 0, 0, fmt.Errorf("no altitude found in %q", s)
+}
+
+func parseNumber(s string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+}
+
+func toMetres(v float64, unit string) float64 {
+	switch strings.ToLower(unit) {
+	case "ft", "feet":
+		return v * metresPerFoot
+	default:
+		return v
+	}
+}
+
+func checkPercent(min, max float64) error {
+	if min < 0 || max > 100 {
+		return fmt.Errorf("caffeine percentage %.2f-%.2f out of range", min, max)
+	}
+	return nil
+}
+
+func checkAltitude(min, max float64) error {
+	if min < -500 || max > 9000 {
+		return fmt.Errorf("altitude %.0f-%.0fm out of range", min, max)
+	}
+	return nil
+}
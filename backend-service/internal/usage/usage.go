@@ -0,0 +1,174 @@
+// Package usage meters inference calls per client and day
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/beanspect/backend-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// monthLayout is the format of month parameters
+const monthLayout = "2006-01"
+
+// MonthStart returns the first instant of the UTC month containing t
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ParseMonth parses a YYYY-MM month, defaulting to the current one
+func ParseMonth(s string) (time.Time, error) {
+	if s == "" {
+		return MonthStart(time.Now()), nil
+	}
+	t, err := time.Parse(monthLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("month must be formatted as YYYY-MM")
+	}
+	return t, nil
+}
+
+// FormatMonth formats a month as YYYY-MM
+func FormatMonth(month time.Time) string {
+	return month.Format(monthLayout)
+}
+
+// Record counts one call of endpoint by client at t
+func Record(db *gorm.DB, client, endpoint string, t time.Time, failed bool) error {
+	errors := 0
+	if failed {
+		errors = 1
+	}
+	t = t.UTC()
+
+	row := models.UsageDaily{
+		ClientKey: client,
+		Day:       time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC),
+		Endpoint:  endpoint,
+		Calls:     1,
+		Errors:    errors,
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "client_key"}, {Name: "day"}, {Name: "endpoint"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"calls":  gorm.Expr("usage_daily.calls + 1"),
+			"errors": gorm.Expr("usage_daily.errors + ?", errors),
+		}),
+	}).Create(&row).Error
+}
+
+// Billable returns the calls of client counted towards its quota in month
+func Billable(db *gorm.DB, client string, month time.Time) (int, error) {
+	var total int
+	err := db.Model(&models.UsageDaily{}).
+		Select("COALESCE(SUM(calls - errors), 0)").
+		Where("client_key = ? AND day >= ? AND day < ?", client, month, month.AddDate(0, 1, 0)).
+		Scan(&total).Error
+	return total, err
+}
+
+// Reserve counts one call of client in month towards its quota before the
+// call is served, returning the calls billable including it. When quota
+// calls are already billable nothing is counted and ok is false; a quota
+// of 0 is unlimited. The check and the increment are a single statement,
+// so concurrent calls cannot overshoot the quota.
+func Reserve(db *gorm.DB, client string, month time.Time, quota int) (used int, ok bool, err error) {
+	used, ok, err = reserve(db, client, month, quota)
+	if ok || err != nil {
+		return used, ok, err
+	}
+
+	// Either the quota is spent or, on the first call of the month, the
+	// counter does not exist yet. Start it from the calls recorded so far
+	// and try again.
+	billable, err := Billable(db, client, month)
+	if err != nil {
+		return 0, false, err
+	}
+	counter := models.UsageMonthly{ClientKey: client, Month: month, Billable: billable}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&counter).Error; err != nil {
+		return 0, false, err
+	}
+	return reserve(db, client, month, quota)
+}
+
+func reserve(db *gorm.DB, client string, month time.Time, quota int) (int, bool, error) {
+	var counter models.UsageMonthly
+	query := db.Model(&counter).Clauses(clause.Returning{}).
+		Where("client_key = ? AND month = ?", client, month)
+	if quota > 0 {
+		query = query.Where("billable < ?", quota)
+	}
+	res := query.UpdateColumn("billable", gorm.Expr("billable + 1"))
+	return counter.Billable, res.RowsAffected > 0, res.Error
+}
+
+// Refund takes back a call reserved for client in month that failed
+func Refund(db *gorm.DB, client string, month time.Time) error {
+	return db.Model(&models.UsageMonthly{}).
+		Where("client_key = ? AND month = ? AND billable > 0", client, month).
+		UpdateColumn("billable", gorm.Expr("billable - 1")).Error
+}
+
+// Days returns the daily usage of client in month, oldest first
+func Days(db *gorm.DB, client string, month time.Time) ([]models.UsageDaily, error) {
+	var days []models.UsageDaily
+	err := db.Where("client_key = ? AND day >= ? AND day < ?", client, month, month.AddDate(0, 1, 0)).
+		Order("day, endpoint").
+		Find(&days).Error
+	return days, err
+}
+
+// ClientUsage is the usage of one client over a month
+type ClientUsage struct {
+	Client    string         `json:"client"`
+	Endpoints map[string]int `json:"endpoints"` // billable calls per endpoint
+	Calls     int            `json:"calls"`
+	Errors    int            `json:"errors"`
+	Billable  int            `json:"billable"`
+}
+
+// Report returns the usage of every client in month, heaviest first
+func Report(db *gorm.DB, month time.Time) ([]*ClientUsage, error) {
+	var days []models.UsageDaily
+	err := db.Where("day >= ? AND day < ?", month, month.AddDate(0, 1, 0)).Find(&days).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byClient := make(map[string]*ClientUsage)
+	for _, d := range days {
+		u, ok := byClient[d.ClientKey]
+		if !ok {
+			u = &ClientUsage{Client: d.ClientKey, Endpoints: make(map[string]int)}
+			byClient[d.ClientKey] = u
+		}
+		u.Endpoints[d.Endpoint] += d.Billable()
+		u.Calls += d.Calls
+		u.Errors += d.Errors
+		u.Billable += d.Billable()
+	}
+
+	report := make([]*ClientUsage, 0, len(byClient))
+	for _, u := range byClient {
+		report = append(report, u)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Billable != report[j].Billable {
+			return report[i].Billable > report[j].Billable
+		}
+		return report[i].Client < report[j].Client
+	})
+	return report, nil
+}
+
+// ParseClient splits a client key into its kind (key, user or ip) and id
+func ParseClient(client string) (kind, id string) {
+	kind, id, _ = strings.Cut(client, ":")
+	return kind, id
+}